@@ -0,0 +1,116 @@
+package zerogate
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// responseCache LRU cache of GET responses carrying an ETag, used to send
+// conditional requests and serve 304 Not Modified responses.
+type responseCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type cacheEntry struct {
+	key      string
+	etag     string
+	response APIResponse
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// cacheKey returns the cache key of a request: method, path and query.
+func cacheKey(method string, u *url.URL) string {
+	return method + " " + u.Path + "?" + u.RawQuery
+}
+
+// get returns the cached entry for the key and marks it as recently used.
+func (rc *responseCache) get(key string) (*cacheEntry, bool) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	element, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.order.MoveToFront(element)
+	return element.Value.(*cacheEntry), true
+}
+
+// add stores a copy of the response under the key, evicting the least
+// recently used entry when the cache is full.
+func (rc *responseCache) add(key, etag string, res *APIResponse) {
+	entry := &cacheEntry{
+		key:  key,
+		etag: etag,
+		response: APIResponse{
+			Body:       append([]byte(nil), res.Body...),
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Headers:    res.Headers.Clone(),
+		},
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	if element, ok := rc.entries[key]; ok {
+		element.Value = entry
+		rc.order.MoveToFront(element)
+		return
+	}
+	rc.entries[key] = rc.order.PushFront(entry)
+	if rc.order.Len() > rc.maxEntries {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// remove drops the entry stored under the key.
+func (rc *responseCache) remove(key string) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	if element, ok := rc.entries[key]; ok {
+		rc.order.Remove(element)
+		delete(rc.entries, key)
+	}
+}
+
+// cachedResponse returns a copy of the cached response, so that callers
+// cannot modify the cached body.
+func (e *cacheEntry) cachedResponse() *APIResponse {
+	return &APIResponse{
+		Body:       append([]byte(nil), e.response.Body...),
+		StatusCode: e.response.StatusCode,
+		Status:     e.response.Status,
+		Headers:    e.response.Headers.Clone(),
+	}
+}
+
+// cacheable reports whether the response can be stored in the cache.
+func cacheable(method string, res *APIResponse) bool {
+	return method == http.MethodGet && res.StatusCode == http.StatusOK && res.Headers.Get("ETag") != ""
+}
+
+// etagsMatch compares two entity tags with the weak comparison used for
+// If-None-Match: W/"v1" matches both W/"v1" and "v1", as proxies compressing
+// a response commonly weaken its tag.
+func etagsMatch(a, b string) bool {
+	return strongETag(a) == strongETag(b)
+}
+
+// strongETag returns the entity tag without its weak indicator.
+func strongETag(etag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+}
@@ -0,0 +1,126 @@
+package zerogate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCacheOption(t *testing.T) {
+	setup(WithResponseCache(10))
+	defer teardown()
+	requests := 0
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		requests++
+		if requests > 1 {
+			assert.Equal(t, `"v1"`, c.Request.Header.Get("If-None-Match"), "cached ETag should be sent")
+			// a stale body would be returned if the 304 body was decoded
+			c.Status(http.StatusNotModified)
+			return
+		}
+		assert.Empty(t, c.Request.Header.Get("If-None-Match"))
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, newSuccessPagingResponse(testTenants(2), 2))
+	})
+
+	first, err := client.Tenant.List(context.Background(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	first.Items[0].Name = "modified by the caller"
+	second, err := client.Tenant.List(context.Background(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, int64(2), second.Total)
+	if assert.Len(t, second.Items, 2) {
+		assert.Equal(t, testTenants(2)[0].Name, second.Items[0].Name, "cached tenant should be returned")
+	}
+
+	_, err = New(testApiKey, testApiSecret, WithResponseCache(0))
+	assert.Error(t, err, "empty cache should be rejected")
+}
+
+func TestResponseCacheWeakETag(t *testing.T) {
+	setup(WithResponseCache(10))
+	defer teardown()
+	var etag string
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		if c.Request.Header.Get("If-None-Match") != "" {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	_, err := client.Tenant.Get(context.Background(), "ten_1")
+	assert.NoError(t, err)
+	for _, etag = range []string{`"v1"`, `W/"v1"`} {
+		tenant, err := client.Tenant.Get(context.Background(), "ten_1")
+		if assert.NoError(t, err, "304 with ETag %s should be served from the cache", etag) {
+			assert.Equal(t, "Test", tenant.Name)
+		}
+	}
+
+	key := cacheKey(http.MethodGet, &url.URL{Path: "/tenants/ten_1"})
+	_, ok := client.cache.get(key)
+	assert.True(t, ok)
+	etag = `W/"v2"`
+	_, err = client.Tenant.Get(context.Background(), "ten_1")
+	assert.ErrorIs(t, err, ErrNotModified, "304 of another representation should not be served from the cache")
+	_, ok = client.cache.get(key)
+	assert.False(t, ok, "mismatched entry should be dropped")
+}
+
+func TestETagsMatch(t *testing.T) {
+	assert.True(t, etagsMatch(`"v1"`, `"v1"`))
+	assert.True(t, etagsMatch(`W/"v1"`, `"v1"`))
+	assert.True(t, etagsMatch(`"v1"`, `W/"v1"`))
+	assert.True(t, etagsMatch(`W/"v1"`, `W/"v1"`))
+	assert.False(t, etagsMatch(`"v1"`, `"v2"`))
+	assert.False(t, etagsMatch(`W/"v1"`, `W/"v2"`))
+	assert.Equal(t, `"v1"`, strongETag(`W/"v1"`))
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	cache := newResponseCache(2)
+	res := &APIResponse{Body: []byte(`{}`), StatusCode: http.StatusOK, Headers: http.Header{}}
+	cache.add("a", `"a"`, res)
+	cache.add("b", `"b"`, res)
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+	// b is now the least recently used entry
+	cache.add("c", `"c"`, res)
+	_, ok = cache.get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	for _, key := range []string{"a", "c"} {
+		_, ok = cache.get(key)
+		assert.True(t, ok, "entry %q should be kept", key)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := cacheKey(http.MethodGet, &url.URL{Path: fmt.Sprintf("/tenants/%d", (i+j)%5)})
+				cache.add(key, `"x"`, res)
+				cache.get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 2, cache.order.Len())
+	assert.Len(t, cache.entries, 2)
+}
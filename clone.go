@@ -0,0 +1,89 @@
+package zerogate
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Clone returns a new client with the configuration and credentials of the
+// client, with opts applied on top, e.g. to use a different base URL or
+// timeout. The clone shares the rate limiter and the global buffer limit with
+// the client, but gets its own response cache.
+func (c *Client) Clone(opts ...Option) (*Client, error) {
+	c.mutex.RLock()
+	httpClient := *c.baseHTTPClient
+	clone := &Client{
+		apiKey:      c.apiKey,
+		apiSecret:   c.apiSecret,
+		bearerToken: c.bearerToken,
+		baseUrl:     c.baseUrl,
+		debug:       c.debug,
+		userAgent:   c.userAgent,
+		headers:     c.headers.Clone(),
+		httpClient:  &httpClient,
+		doer:        c.doer,
+		logger:      c.logger,
+
+		debugWriter:    c.debugWriter,
+		debugBodyLimit: c.debugBodyLimit,
+	}
+	c.mutex.RUnlock()
+
+	clone.timeout = c.timeout
+	if c.endpointTimeouts != nil {
+		clone.endpointTimeouts = make(map[string]time.Duration, len(c.endpointTimeouts))
+		for pattern, timeout := range c.endpointTimeouts {
+			clone.endpointTimeouts[pattern] = timeout
+		}
+	}
+	clone.retry = c.retry
+	clone.retryPredicate = c.retryPredicate
+	clone.failoverURLs = slices.Clone(c.failoverURLs)
+	clone.limiter = c.limiter
+	clone.verifyDigest = c.verifyDigest
+	clone.prefer = c.prefer
+	clone.slogger = c.slogger
+	clone.middlewares = slices.Clone(c.middlewares)
+	clone.sharedTransport = c.sharedTransport
+	clone.proxy = c.proxy
+	clone.tlsClientConfig = c.tlsClientConfig
+	clone.tracer = c.tracer
+	clone.metrics = c.metrics
+	clone.observer = c.observer
+	clone.idempotentDelete = c.idempotentDelete
+	clone.acceptFallback = c.acceptFallback
+	clone.strictDecoding = c.strictDecoding
+	clone.compressRequests = c.compressRequests
+	clone.panicRecovery = c.panicRecovery
+	clone.clockSkewTolerance = c.clockSkewTolerance
+	clone.maxRequestSize = c.maxRequestSize
+	clone.maxResponseSize = c.maxResponseSize
+	if c.cache != nil {
+		clone.cache = newResponseCache(c.cache.maxEntries)
+	}
+	clone.bodyTransformer = c.bodyTransformer
+	clone.requestHooks = slices.Clone(c.requestHooks)
+	clone.responseHooks = slices.Clone(c.responseHooks)
+	clone.bufferLimit = c.bufferLimit
+	clone.bufferSem = c.bufferSem
+	clone.nonceGenerator = c.nonceGenerator
+	clone.nonceResolution = c.nonceResolution
+	clone.signature = c.signature
+	clone.clock = c.clock
+	clone.common.client = clone
+
+	err := clone.parseOptions(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("options parsing failed: %w", err)
+	}
+	err = clone.finish()
+	if err != nil {
+		return nil, err
+	}
+	err = clone.checkCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
@@ -0,0 +1,60 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Clone(t *testing.T) {
+	calls := 0
+	count := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return next.RoundTrip(req)
+		})
+	}
+	setup(WithHeader("X-Team", "platform"), WithMiddleware(count))
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, c.GetHeader("X-Team"))
+	})
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"other"`))
+	}))
+	defer other.Close()
+
+	clone, err := client.Clone(BaseURL(other.URL), WithTimeout(time.Second), WithHeader("X-Team", "billing"))
+	if err != nil {
+		assert.NoError(t, err, "clone failed")
+		return
+	}
+	assert.Equal(t, other.URL, clone.baseUrl)
+	assert.Equal(t, server.URL, client.baseUrl, "original base url should be unchanged")
+	assert.Equal(t, "platform", client.headers.Get("X-Team"), "original headers should be unchanged")
+	assert.Zero(t, client.timeout, "original timeout should be unchanged")
+	assert.Equal(t, client.apiKey, clone.apiKey, "credentials should be shared")
+	assert.NotSame(t, client.httpClient, clone.httpClient, "HTTP client should be copied")
+	assert.Same(t, clone, clone.Tenant.client, "services should use the clone")
+
+	res, err := client.doRequestString(context.Background(), http.MethodGet, "/tenants", nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "platform", res)
+	res, err = clone.doRequestString(context.Background(), http.MethodGet, "/tenants", nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", res)
+	assert.Equal(t, 2, calls, "middlewares should wrap the clone transport once")
+
+	assert.NoError(t, clone.SetCredentials("key_rotated", "secret_rotated"))
+	assert.Equal(t, testApiKey, client.apiKey, "rotating the clone credentials should not affect the original")
+
+	_, err = client.Clone(WithTimeout(0))
+	assert.Error(t, err, "invalid options should be rejected")
+}
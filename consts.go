@@ -1,6 +1,30 @@
 package zerogate
 
+import "time"
+
 const (
 	baseUrl   = "https://api.zerogate.com/public/v1"
 	userAgent = "zerogate-go"
+
+	// environment variables read by NewFromEnv
+	envAPIKey    = "ZEROGATE_API_KEY"
+	envAPISecret = "ZEROGATE_API_SECRET"
+	envBaseURL   = "ZEROGATE_BASE_URL"
+
+	// listAllPageSize is the page size used when auto-paginating list endpoints.
+	listAllPageSize = 100
+
+	// batchConcurrency is the number of concurrent requests used by batch operations.
+	batchConcurrency = 5
+
+	// compressionThreshold is the body size in bytes above which request
+	// bodies are compressed when request compression is enabled.
+	compressionThreshold = 1024
+
+	// defaultDebugBodyLimit is the number of body bytes kept in debug dumps.
+	defaultDebugBodyLimit = 64 << 10
+
+	// defaultClockSkewTolerance is the clock skew tolerated by CheckClockSkew
+	// unless set with WithClockSkewTolerance.
+	defaultClockSkewTolerance = 30 * time.Second
 )
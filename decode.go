@@ -0,0 +1,94 @@
+package zerogate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// decode unmarshals a JSON response body into v. With strict decoding,
+// fields unknown to v are an error rather than silently dropped.
+func (c *Client) decode(body []byte, v interface{}) error {
+	if !c.strictDecoding {
+		return json.Unmarshal(body, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	// like json.Unmarshal, reject data after the value
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// decodeData decodes the data of a success response, kind names the data in
+// the error message. An empty response decodes to the zero value.
+func decodeData[T any](c *Client, res *APIResponse, kind string) (T, error) {
+	var r SuccessResponse[T]
+	if res.NotModified {
+		return r.Data, ErrNotModified
+	}
+	if res.empty() {
+		return r.Data, nil
+	}
+	err := c.decode(res.Body, &r)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to unmarshal %s JSON data: %w", kind, err)
+	}
+	return r.Data, nil
+}
+
+// decodeList decodes a paging response into a list, kind names the items in
+// the error message. An empty response decodes to an empty list.
+func decodeList[T any](c *Client, res *APIResponse, kind string) (*List[T], error) {
+	if res.NotModified {
+		return nil, ErrNotModified
+	}
+	if res.empty() {
+		return &List[T]{}, nil
+	}
+	var r SuccessPagingResponse[T]
+	err := c.decode(res.Body, &r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s JSON data: %w", kind, err)
+	}
+	return &List[T]{
+		Items: r.Data,
+		Total: r.Total,
+	}, nil
+}
+
+// decodePageLenient decodes a paging response, skipping and logging list
+// items that decode fails on instead of failing the whole page. It returns
+// the number of skipped items.
+func decodePageLenient[T any](logger *log.Logger, body []byte, decode func([]byte, interface{}) error) (*SuccessPagingResponse[T], int, error) {
+	var raw SuccessPagingResponse[json.RawMessage]
+	err := json.Unmarshal(body, &raw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal paging JSON data: %w", err)
+	}
+	r := &SuccessPagingResponse[T]{
+		Success: raw.Success,
+		Data:    make([]T, 0, len(raw.Data)),
+		Total:   raw.Total,
+	}
+	skipped := 0
+	for i, item := range raw.Data {
+		var v T
+		if err := decode(item, &v); err != nil {
+			logger.Printf("skipping malformed list item %d: %v", i, err)
+			skipped++
+			continue
+		}
+		r.Data = append(r.Data, v)
+	}
+	return r, skipped, nil
+}
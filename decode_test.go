@@ -0,0 +1,126 @@
+package zerogate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodePageLenient(t *testing.T) {
+	var buf bytes.Buffer
+	body := []byte(`{"success":true,"total":4,"data":[{"name":"a"},{"name":1},{"name":"c"},"bad"]}`)
+	r, skipped, err := decodePageLenient[*Tenant](log.New(&buf, "", 0), body, json.Unmarshal)
+	if err != nil {
+		assert.NoError(t, err)
+		return
+	}
+	assert.Equal(t, 2, skipped)
+	assert.Equal(t, int64(4), r.Total)
+	if assert.Len(t, r.Data, 2) {
+		assert.Equal(t, "a", r.Data[0].Name)
+		assert.Equal(t, "c", r.Data[1].Name)
+	}
+	assert.Contains(t, buf.String(), "skipping malformed list item 1")
+	assert.Contains(t, buf.String(), "skipping malformed list item 3")
+
+	_, _, err = decodePageLenient[*Tenant](log.New(&buf, "", 0), []byte(`{"data":{}}`), json.Unmarshal)
+	assert.Error(t, err, "malformed envelope should fail")
+}
+
+func TestTenantService_ListLenient(t *testing.T) {
+	var buf bytes.Buffer
+	setup(WithLogger(log.New(&buf, "", 0)))
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		c.Data(http.StatusOK, "application/json", []byte(`{"success":true,"total":3,"data":[
+			{"id":"ten_1","name":"one"},
+			{"id":"ten_2","name":"two","created":"not a timestamp"},
+			{"id":"ten_3","name":"three"}
+		]}`))
+	})
+
+	_, err := client.Tenant.List(context.TODO(), nil)
+	assert.Error(t, err, "strict list should fail on the malformed tenant")
+
+	tenants, skipped, err := client.Tenant.ListLenient(context.TODO(), nil)
+	if err != nil {
+		assert.NoError(t, err)
+		return
+	}
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, int64(3), tenants.Total)
+	if assert.Len(t, tenants.Items, 2) {
+		assert.Equal(t, "ten_1", tenants.Items[0].Id)
+		assert.Equal(t, "ten_3", tenants.Items[1].Id)
+	}
+	assert.Contains(t, buf.String(), "skipping malformed list item 1")
+}
+
+func TestStrictDecoding(t *testing.T) {
+	handler := func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(`{"success":true,"data":{"id":"ten_ea87af463d9fc38203690805c1c1fa33","name":"Test","region":"eu-west-1"}}`))
+	}
+
+	setup()
+	router.GET("/tenants/:tenantId", handler)
+	tenant, err := client.Tenant.Get(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	if assert.NoError(t, err, "unknown field should be dropped by default") {
+		assert.Equal(t, "Test", tenant.Name)
+	}
+	teardown()
+
+	setup(WithStrictDecoding())
+	defer teardown()
+	router.GET("/tenants/:tenantId", handler)
+	_, err = client.Tenant.Get(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	assert.ErrorContains(t, err, `unknown field "region"`, "unknown field should fail in strict mode")
+}
+
+func TestClient_DecodeStrict(t *testing.T) {
+	client := &Client{strictDecoding: true}
+	var v struct{ Name string }
+	assert.NoError(t, client.decode([]byte(`{"Name":"a"}`), &v))
+	assert.Error(t, client.decode([]byte(`{"Name":"a"}{}`), &v), "trailing data should be rejected")
+	assert.Error(t, client.decode([]byte(`{"Other":1}`), &v), "unknown field should be rejected")
+}
+
+func TestDecodeData(t *testing.T) {
+	client := &Client{}
+	tenant, err := decodeData[*Tenant](client, &APIResponse{Body: []byte(`{"success":true,"data":{"id":"ten_1","name":"Test"}}`)}, "tenant")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test", tenant.Name)
+	}
+	_, err = decodeData[*Tenant](client, &APIResponse{Body: []byte(`{"data":[]}`)}, "tenant")
+	assert.ErrorContains(t, err, "failed to unmarshal tenant JSON data: ")
+
+	list, err := decodeList[*Tenant](client, &APIResponse{Body: []byte(`{"success":true,"data":[{"id":"ten_1"}],"total":3}`)}, "tenant")
+	if assert.NoError(t, err) {
+		assert.Len(t, list.Items, 1)
+		assert.Equal(t, int64(3), list.Total)
+	}
+	_, err = decodeList[*Tenant](client, &APIResponse{Body: []byte(`{"data":{}}`)}, "tenant")
+	assert.ErrorContains(t, err, "failed to unmarshal tenant JSON data: ")
+}
+
+func TestTenantService_DecodeError(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(`{"success":true,"data":"not a tenant"}`))
+	})
+	router.GET("/tenants", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(`{"success":true,"data":"not a list"}`))
+	})
+
+	_, err := client.Tenant.Get(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	assert.ErrorContains(t, err, "failed to unmarshal tenant JSON data: ")
+	_, err = client.Tenant.List(context.TODO(), nil)
+	assert.ErrorContains(t, err, "failed to unmarshal tenant JSON data: ")
+}
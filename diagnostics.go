@@ -0,0 +1,260 @@
+package zerogate
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TLSInfo TLS connection details of the API endpoint
+type TLSInfo struct {
+	Version      string
+	CipherSuite  string
+	ServerName   string
+	Certificates []*CertificateInfo
+
+	// VerifyError is set when the certificate chain could not be verified
+	// against the configured root CAs.
+	VerifyError error
+}
+
+// CertificateInfo certificate details
+type CertificateInfo struct {
+	Subject      string
+	Issuer       string
+	SerialNumber string
+	NotBefore    time.Time
+	NotAfter     time.Time
+	DNSNames     []string
+	IPAddresses  []string
+}
+
+// InspectTLS connects to the configured base URL and returns the server
+// certificate chain without making an API call.
+func (c *Client) InspectTLS(ctx context.Context) (*TLSInfo, error) {
+	c.mutex.RLock()
+	baseUrl := c.baseUrl
+	c.mutex.RUnlock()
+
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("base url %q does not use https", baseUrl)
+	}
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	config := c.tlsConfig()
+	config.ServerName = host
+	// verification is done below so that the chain can be reported even
+	// when it is not trusted.
+	config.InsecureSkipVerify = true
+
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	info := &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  host,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, newCertificateInfo(cert))
+	}
+	info.VerifyError = verifyChain(state.PeerCertificates, config.RootCAs, host)
+
+	return info, nil
+}
+
+// tlsConfig returns a copy of the TLS configuration used by the HTTP client.
+func (c *Client) tlsConfig() *tls.Config {
+	httpClient := c.getClient()
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if httpClient.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if ok && transport.TLSClientConfig != nil {
+		return transport.TLSClientConfig.Clone()
+	}
+	return &tls.Config{}
+}
+
+func newCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: cert.SerialNumber.String(),
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+		DNSNames:     cert.DNSNames,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	return info
+}
+
+func verifyChain(certs []*x509.Certificate, roots *x509.CertPool, host string) error {
+	if len(certs) == 0 {
+		return errors.New("server presented no certificates")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// Diagnostics connection diagnostics of the API endpoint. Connection timings
+// are zero when an idle connection was reused.
+type Diagnostics struct {
+	DNSLookup    time.Duration
+	TCPConnect   time.Duration
+	TLSHandshake time.Duration
+	// FirstByte is the time from sending the request to the first response byte.
+	FirstByte time.Duration
+	Total     time.Duration
+
+	StatusCode          int
+	CredentialsAccepted bool
+}
+
+// Diagnose sends a signed request to the API and reports connection timings
+// and whether the credentials were accepted. The returned error is only set
+// when the API could not be reached, in which case the diagnostics collected
+// so far are returned with it.
+func (c *Client) Diagnose(ctx context.Context) (*Diagnostics, error) {
+	var mutex sync.Mutex
+	var dnsStart, connectStart, tlsStart, start time.Time
+	diagnostics := &Diagnostics{}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mutex.Lock()
+			dnsStart = time.Now()
+			mutex.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mutex.Lock()
+			diagnostics.DNSLookup = time.Since(dnsStart)
+			mutex.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			mutex.Lock()
+			if connectStart.IsZero() {
+				connectStart = time.Now()
+			}
+			mutex.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			mutex.Lock()
+			if err == nil {
+				diagnostics.TCPConnect = time.Since(connectStart)
+			}
+			mutex.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			mutex.Lock()
+			tlsStart = time.Now()
+			mutex.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mutex.Lock()
+			diagnostics.TLSHandshake = time.Since(tlsStart)
+			mutex.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mutex.Lock()
+			diagnostics.FirstByte = time.Since(start)
+			mutex.Unlock()
+		},
+	}
+
+	start = time.Now()
+	res, err := c.get(httptrace.WithClientTrace(ctx, trace), "/tenants", map[string][]string{"page_size": {"1"}}, nil)
+	mutex.Lock()
+	defer mutex.Unlock()
+	diagnostics.Total = time.Since(start)
+
+	var apiErr *Error
+	switch {
+	case err == nil:
+		diagnostics.StatusCode = res.StatusCode
+		diagnostics.CredentialsAccepted = true
+	case errors.As(err, &apiErr):
+		diagnostics.StatusCode = apiErr.StatusCode
+		diagnostics.CredentialsAccepted = apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusForbidden
+	default:
+		return diagnostics, err
+	}
+	return diagnostics, nil
+}
+
+// Ping sends a signed request to the health endpoint, a cheap way to check
+// that the API is reachable and the credentials are accepted, e.g. at
+// startup. Error responses are returned as *Error.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.get(ctx, "/health", nil, nil)
+	return err
+}
+
+// CheckClockSkew compares the local clock against the Date header of a
+// response from the API and returns the skew, positive when the local clock
+// is ahead. Nonces carry a timestamp, so a drifted clock makes the server
+// reject every signature. ErrClockSkew is returned along with the skew when
+// it exceeds the tolerance set with WithClockSkewTolerance. As the Date
+// header has a resolution of one second, so has the skew.
+func (c *Client) CheckClockSkew(ctx context.Context) (time.Duration, error) {
+	// the Date header is also sent with a rejected signature, but a signed
+	// request lets health endpoints requiring authentication answer as well
+	sr, err := c.newSignedRequest(ctx, http.MethodGet, "/health", nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := c.clock()
+	resp, err := c.roundTrip(sr.req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	end := c.clock()
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, errors.New("response has no Date header")
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Date header %q: %w", date, err)
+	}
+	// the server time was taken somewhere between sending and receiving
+	local := start.Add(end.Sub(start) / 2)
+	skew := local.Sub(serverTime).Truncate(time.Second)
+	if skew > c.clockSkewTolerance || skew < -c.clockSkewTolerance {
+		return skew, fmt.Errorf("%w: local clock is %s off the server clock", ErrClockSkew, skew)
+	}
+	return skew, nil
+}
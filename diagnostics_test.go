@@ -0,0 +1,226 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_InspectTLS(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	client, err := New(testApiKey, testApiSecret, HTTPClient(tlsServer.Client()), BaseURL(tlsServer.URL))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	info, err := client.InspectTLS(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "inspect TLS error")
+		return
+	}
+	assert.Equal(t, "127.0.0.1", info.ServerName)
+	assert.NotEmpty(t, info.Version, "TLS version is empty")
+	assert.NotEmpty(t, info.CipherSuite, "cipher suite is empty")
+	assert.NoError(t, info.VerifyError, "certificate should be trusted")
+	if assert.Len(t, info.Certificates, 1) {
+		cert := info.Certificates[0]
+		leaf := tlsServer.Certificate()
+		assert.Equal(t, leaf.Subject.String(), cert.Subject)
+		assert.Equal(t, leaf.Issuer.String(), cert.Issuer)
+		assert.Equal(t, leaf.NotAfter, cert.NotAfter)
+		assert.Equal(t, leaf.DNSNames, cert.DNSNames)
+		assert.Contains(t, cert.IPAddresses, "127.0.0.1")
+	}
+}
+
+func TestClient_InspectTLSUntrusted(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	client, err := New(testApiKey, testApiSecret, BaseURL(tlsServer.URL))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	info, err := client.InspectTLS(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "inspect TLS error")
+		return
+	}
+	assert.Error(t, info.VerifyError, "self signed certificate should not be trusted")
+	assert.Len(t, info.Certificates, 1)
+}
+
+func TestClient_InspectTLSPlainHTTP(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret, BaseURL("http://localhost:8080/public/v1"))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	_, err = client.InspectTLS(context.TODO())
+	assert.Error(t, err, "plain http base url should be rejected")
+}
+
+func TestClient_Diagnose(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, newSuccessPagingResponse([]*Tenant{}, 0))
+	})
+	// resolve a host name so that the DNS lookup is traced
+	client.baseUrl = strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	diagnostics, err := client.Diagnose(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "diagnose error")
+		return
+	}
+	assert.Positive(t, diagnostics.DNSLookup, "DNS lookup time should be populated")
+	assert.Positive(t, diagnostics.TCPConnect, "TCP connect time should be populated")
+	assert.Zero(t, diagnostics.TLSHandshake, "plain HTTP should have no TLS handshake")
+	assert.Positive(t, diagnostics.FirstByte, "first byte time should be populated")
+	assert.GreaterOrEqual(t, diagnostics.Total, diagnostics.FirstByte)
+	assert.Equal(t, http.StatusOK, diagnostics.StatusCode)
+	assert.True(t, diagnostics.CredentialsAccepted)
+}
+
+func TestClient_DiagnoseTLS(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"success":false,"error_code":401,"error_message":"invalid signature"}`))
+	}))
+	defer tlsServer.Close()
+	client, err := New(testApiKey, testApiSecret, HTTPClient(tlsServer.Client()), BaseURL(tlsServer.URL))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+
+	diagnostics, err := client.Diagnose(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "diagnose error")
+		return
+	}
+	assert.Positive(t, diagnostics.TLSHandshake, "TLS handshake time should be populated")
+	assert.Equal(t, http.StatusUnauthorized, diagnostics.StatusCode)
+	assert.False(t, diagnostics.CredentialsAccepted, "rejected credentials should be reported")
+}
+
+func TestClient_DiagnoseUnreachable(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret, BaseURL("http://127.0.0.1:1"))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	diagnostics, err := client.Diagnose(context.TODO())
+	assert.Error(t, err, "unreachable endpoint should fail")
+	assert.NotNil(t, diagnostics, "partial diagnostics should be returned")
+}
+
+func TestClient_Ping(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/health", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	assert.NoError(t, client.Ping(context.TODO()))
+}
+
+func TestClient_PingUnauthorized(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusUnauthorized, newErrorResponse(http.StatusUnauthorized, errors.New("invalid signature")))
+	})
+
+	err := client.Ping(context.TODO())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, "invalid signature", apiErr.Response.ErrorMessage)
+	}
+}
+
+func TestClient_CheckClockSkew(t *testing.T) {
+	setup()
+	defer teardown()
+	var offset time.Duration
+	router.GET("/health", func(c *gin.Context) {
+		testSignature(c, t)
+		c.Header("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	skew, err := client.CheckClockSkew(context.TODO())
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, skew.Abs(), time.Second, "skew should be within the Date resolution")
+
+	offset = -2 * time.Minute
+	skew, err = client.CheckClockSkew(context.TODO())
+	assert.ErrorIs(t, err, ErrClockSkew)
+	assert.InDelta(t, (2 * time.Minute).Seconds(), skew.Seconds(), 1, "local clock should be reported ahead")
+
+	offset = 10 * time.Second
+	skew, err = client.CheckClockSkew(context.TODO())
+	assert.NoError(t, err, "small skew should be tolerated")
+	assert.InDelta(t, (-10 * time.Second).Seconds(), skew.Seconds(), 1, "local clock should be reported behind")
+}
+
+func TestClient_CheckClockSkewRejected(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/health", func(c *gin.Context) {
+		c.Header("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		c.JSON(http.StatusUnauthorized, newErrorResponse(http.StatusUnauthorized, errors.New("expired nonce")))
+	})
+
+	skew, err := client.CheckClockSkew(context.TODO())
+	assert.ErrorIs(t, err, ErrClockSkew, "the skew should be reported when the signature is rejected")
+	assert.InDelta(t, (-time.Hour).Seconds(), skew.Seconds(), 1)
+}
+
+func TestClient_CheckClockSkewNoDate(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/health", func(c *gin.Context) {
+		c.Writer.Header()["Date"] = nil
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.CheckClockSkew(context.TODO())
+	assert.Error(t, err, "missing Date header should fail")
+}
+
+func TestClient_CheckClockSkewTolerance(t *testing.T) {
+	setup(WithClockSkewTolerance(5*time.Second), WithHeader("X-Team", "platform"), WithRateLimit(1, 1))
+	defer teardown()
+	router.GET("/health", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, userAgent, c.GetHeader("User-Agent"), "default headers should be sent")
+		assert.NotEmpty(t, c.GetHeader(requestIDHeader), "a request ID should be sent")
+		assert.Equal(t, "platform", c.GetHeader("X-Team"), "client headers should be sent")
+		assert.Equal(t, "ten_1", c.GetHeader("X-Tenant-ID"), "context headers should be sent")
+		c.Header("Date", time.Now().Add(-10*time.Second).UTC().Format(http.TimeFormat))
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	skew, err := client.CheckClockSkew(WithContextHeader(context.TODO(), "X-Tenant-ID", "ten_1"))
+	assert.ErrorIs(t, err, ErrClockSkew, "skew beyond the tolerance should be reported")
+	assert.InDelta(t, (10 * time.Second).Seconds(), skew.Seconds(), 1)
+	assert.Less(t, client.limiter.Tokens(), 1.0, "the probe should wait for the rate limiter")
+
+	_, err = New(testApiKey, testApiSecret, WithClockSkewTolerance(0))
+	assert.Error(t, err, "non-positive tolerance should be rejected")
+}
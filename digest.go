@@ -0,0 +1,56 @@
+package zerogate
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// digestAlgorithms maps the supported Digest header algorithms to their hash.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// verifyDigest checks the body against the Content-MD5 and Digest response
+// headers. Responses without a supported digest are accepted.
+func verifyDigest(header http.Header, body []byte) error {
+	if expected := header.Get("Content-MD5"); expected != "" {
+		if err := compareDigest("MD5", md5.New, expected, body); err != nil {
+			return err
+		}
+	}
+	for _, value := range header.Values("Digest") {
+		for _, digest := range strings.Split(value, ",") {
+			algorithm, expected, ok := strings.Cut(strings.TrimSpace(digest), "=")
+			if !ok {
+				continue
+			}
+			newHash, ok := digestAlgorithms[strings.ToLower(algorithm)]
+			if !ok {
+				continue
+			}
+			if err := compareDigest(algorithm, newHash, expected, body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func compareDigest(algorithm string, newHash func() hash.Hash, expected string, body []byte) error {
+	h := newHash()
+	h.Write(body)
+	actual := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(actual), []byte(strings.TrimSpace(expected))) != 1 {
+		return fmt.Errorf("%w: %s expected %s got %s", ErrDigestMismatch, algorithm, expected, actual)
+	}
+	return nil
+}
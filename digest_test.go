@@ -0,0 +1,101 @@
+package zerogate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDigest(sum []byte) string {
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+func TestClient_VerifyResponseDigest(t *testing.T) {
+	setup(WithVerifyResponseDigest())
+	defer teardown()
+	body := []byte(`{"success":true,"data":"ok"}`)
+	md5Sum := md5.Sum(body)
+	sha256Sum := sha256.Sum256(body)
+	tamperedSum := sha256.Sum256([]byte(`{"success":true,"data":"tampered"}`))
+	router.GET("/md5", func(c *gin.Context) {
+		c.Header("Content-MD5", testDigest(md5Sum[:]))
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/digest", func(c *gin.Context) {
+		c.Header("Digest", "unknown=abc, SHA-256="+testDigest(sha256Sum[:]))
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/tampered", func(c *gin.Context) {
+		c.Header("Digest", "SHA-256="+testDigest(tamperedSum[:]))
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET("/none", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	_, err := client.get(context.Background(), "/md5", nil, nil)
+	assert.NoError(t, err, "matching Content-MD5 should be accepted")
+	_, err = client.get(context.Background(), "/digest", nil, nil)
+	assert.NoError(t, err, "matching Digest should be accepted")
+	_, err = client.get(context.Background(), "/none", nil, nil)
+	assert.NoError(t, err, "responses without digest should be accepted")
+	_, err = client.get(context.Background(), "/tampered", nil, nil)
+	assert.ErrorIs(t, err, ErrDigestMismatch, "tampered body should be rejected")
+}
+
+func TestClient_ResponseDigestDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tampered", func(c *gin.Context) {
+		c.Header("Content-MD5", "AAAAAAAAAAAAAAAAAAAAAA==")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/tampered", nil, nil)
+	assert.NoError(t, err, "digest should not be verified unless enabled")
+}
+
+func TestClient_VerifyResponseDigestGzip(t *testing.T) {
+	setup(WithVerifyResponseDigest())
+	defer teardown()
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(`{"success":true,"data":"ok"}`))
+	zw.Close()
+	sum := sha256.Sum256(compressed.Bytes())
+	tamperedSum := sha256.Sum256([]byte(`{"success":true,"data":"ok"}`))
+	router.GET("/gzip", func(c *gin.Context) {
+		assert.Equal(t, "gzip", c.GetHeader("Accept-Encoding"))
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Digest", "SHA-256="+testDigest(sum[:]))
+		c.Data(http.StatusOK, "application/json", compressed.Bytes())
+	})
+	router.GET("/gzip-tampered", func(c *gin.Context) {
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Digest", "SHA-256="+testDigest(tamperedSum[:]))
+		c.Data(http.StatusOK, "application/json", compressed.Bytes())
+	})
+
+	for _, acceptEncoding := range []string{"", "gzip"} {
+		var headers http.Header
+		if acceptEncoding != "" {
+			headers = http.Header{"Accept-Encoding": {acceptEncoding}}
+		}
+		res, err := client.get(context.Background(), "/gzip", nil, headers)
+		if assert.NoError(t, err, "digest of the compressed bytes should be accepted") {
+			s, err := decodeString(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", s, "body should be decompressed")
+		}
+	}
+	_, err := client.get(context.Background(), "/gzip-tampered", nil, nil)
+	assert.ErrorIs(t, err, ErrDigestMismatch, "digest of the decompressed bytes should be rejected")
+}
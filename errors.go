@@ -1,22 +1,142 @@
 package zerogate
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
 
 const (
-	errEmptyCredentials = "API key & secret must not be empty"
+	errEmptyCredentials       = "API key & secret must not be empty"
+	errConflictingCredentials = "API key & secret and bearer token are mutually exclusive"
+)
+
+// Sentinel errors matched by errors.Is against an *Error with the
+// corresponding HTTP status code
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServerError  = errors.New("server error")
 )
 
+// ErrDigestMismatch is returned when a response body does not match the
+// digest sent by the server.
+var ErrDigestMismatch = errors.New("response digest mismatch")
+
+// ErrNotModified is returned by service methods when the server answers a
+// conditional request, e.g. one sent with WithIfModifiedSince, with 304 Not
+// Modified.
+var ErrNotModified = errors.New("not modified")
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set
+// with WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrRequestTooLarge is returned when a request body exceeds the limit set
+// with WithMaxRequestSize.
+var ErrRequestTooLarge = errors.New("request too large")
+
+// ErrClockSkew is returned by CheckClockSkew when the local clock is too far
+// off the server clock for request nonces to be accepted.
+var ErrClockSkew = errors.New("clock skew too large")
+
+// ErrWebhookSignatureMismatch is returned by VerifyWebhook when the signature
+// does not match the body.
+var ErrWebhookSignatureMismatch = errors.New("webhook signature mismatch")
+
 type Error struct {
 	// Response is the error response from the server
 	Response ErrorResponse
 
 	// StatusCode is the HTTP status code from the response.
 	StatusCode int
+
+	// RetryAfter is the delay requested by the server through the Retry-After
+	// header of a 429 or 503 response, zero when absent.
+	RetryAfter time.Duration
+
+	// Body is the raw response body when it was not a JSON error response,
+	// in which case Response only holds the status text.
+	Body string
+
+	// RequestID is the X-Request-ID echoed by the server, or the one sent,
+	// to find the request in the server logs.
+	RequestID string
+
+	// Signing holds what the client signed when a 401 response was received
+	// with debug enabled, nil otherwise.
+	Signing *SigningDebug
+}
+
+// SigningDebug the signed request details, to be compared with the server
+// logs when a signature is rejected. The secret is never included.
+type SigningDebug struct {
+	Nonce string
+	// Message is the exact message that was signed: method, path, nonce and
+	// body concatenated.
+	Message string
 }
 
-func (e Error) Error() string {
+func (e *Error) Error() string {
 	if e.Response.ErrorMessage != "" && e.StatusCode > 0 {
 		return fmt.Sprintf("%s (%d)", e.Response.ErrorMessage, e.StatusCode)
 	}
 	return fmt.Sprintf("unknown error (%d)", e.StatusCode)
 }
+
+// Is reports whether the error matches one of the sentinel errors, e.g.
+// errors.Is(err, ErrNotFound).
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// FieldErrors returns the per-field messages of a validation failure keyed by
+// field name, nil when the server sent none.
+func (e *Error) FieldErrors() map[string]string {
+	return e.Response.Fields
+}
+
+// statusCode returns the HTTP status code of a ZeroGate error, or 0 if err
+// is not one.
+func statusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a ZeroGate 404 Not Found error.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a ZeroGate 401 Unauthorized error.
+func IsUnauthorized(err error) bool {
+	return statusCode(err) == http.StatusUnauthorized
+}
+
+// IsRateLimited reports whether err is a ZeroGate 429 Too Many Requests error.
+func IsRateLimited(err error) bool {
+	return statusCode(err) == http.StatusTooManyRequests
+}
+
+// IsServerError reports whether err is a ZeroGate 5xx error.
+func IsServerError(err error) bool {
+	return statusCode(err) >= http.StatusInternalServerError
+}
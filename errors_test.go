@@ -0,0 +1,150 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		notFound     bool
+		unauthorized bool
+		rateLimited  bool
+		serverError  bool
+	}{
+		{name: "nil", err: nil},
+		{name: "other error", err: errors.New("boom")},
+		{name: "bad request", err: &Error{StatusCode: http.StatusBadRequest}},
+		{name: "unauthorized", err: &Error{StatusCode: http.StatusUnauthorized}, unauthorized: true},
+		{name: "not found", err: &Error{StatusCode: http.StatusNotFound}, notFound: true},
+		{name: "wrapped not found", err: fmt.Errorf("get tenant: %w", &Error{StatusCode: http.StatusNotFound}), notFound: true},
+		{name: "rate limited", err: &Error{StatusCode: http.StatusTooManyRequests}, rateLimited: true},
+		{name: "internal error", err: &Error{StatusCode: http.StatusInternalServerError}, serverError: true},
+		{name: "bad gateway", err: &Error{StatusCode: http.StatusBadGateway}, serverError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.notFound, IsNotFound(test.err), "IsNotFound")
+			assert.Equal(t, test.unauthorized, IsUnauthorized(test.err), "IsUnauthorized")
+			assert.Equal(t, test.rateLimited, IsRateLimited(test.err), "IsRateLimited")
+			assert.Equal(t, test.serverError, IsServerError(test.err), "IsServerError")
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+	}{
+		{status: http.StatusUnauthorized, target: ErrUnauthorized},
+		{status: http.StatusForbidden, target: ErrForbidden},
+		{status: http.StatusNotFound, target: ErrNotFound},
+		{status: http.StatusTooManyRequests, target: ErrRateLimited},
+		{status: http.StatusInternalServerError, target: ErrServerError},
+		{status: http.StatusServiceUnavailable, target: ErrServerError},
+	}
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrServerError}
+	for _, test := range tests {
+		var err error = fmt.Errorf("wrapped: %w", &Error{StatusCode: test.status})
+		for _, sentinel := range sentinels {
+			assert.Equal(t, sentinel == test.target, errors.Is(err, sentinel), "status %d against %v", test.status, sentinel)
+		}
+	}
+	assert.False(t, errors.Is(&Error{StatusCode: http.StatusBadRequest}, ErrNotFound))
+	assert.False(t, errors.Is(errors.New("not found"), ErrNotFound), "unrelated errors should not match")
+}
+
+func TestError_Error(t *testing.T) {
+	var err error = &Error{StatusCode: http.StatusNotFound, Response: ErrorResponse{ErrorMessage: "tenant not found"}}
+	assert.Equal(t, "tenant not found (404)", err.Error())
+	err = &Error{StatusCode: http.StatusBadGateway}
+	assert.Equal(t, "unknown error (502)", err.Error())
+}
+
+func TestError_SigningDebug(t *testing.T) {
+	setup(Debug(true), WithLogger(log.New(io.Discard, "", 0)))
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		c.JSON(http.StatusUnauthorized, newErrorResponse(http.StatusUnauthorized, errors.New("invalid signature")))
+	})
+
+	_, err := client.post(context.Background(), "/tenants", nil, []byte(`{"name":"Test"}`), nil)
+	var apiErr *Error
+	if !assert.ErrorAs(t, err, &apiErr) || !assert.NotNil(t, apiErr.Signing, "signing details should be attached") {
+		return
+	}
+	assert.NotEmpty(t, apiErr.Signing.Nonce)
+	assert.Equal(t, "POST/tenants"+apiErr.Signing.Nonce+`{"name":"Test"}`, apiErr.Signing.Message)
+	assert.NotContains(t, apiErr.Signing.Message, testApiSecret)
+
+	// signing details are only collected in debug mode
+	client.debug = false
+	_, err = client.post(context.Background(), "/tenants", nil, nil, nil)
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Nil(t, apiErr.Signing)
+	}
+}
+
+func TestError_NonJSONBody(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/html", func(c *gin.Context) {
+		c.Data(http.StatusBadGateway, "text/html", []byte("<html><body>502 Bad Gateway</body></html>"))
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.get(context.Background(), "/html", nil, nil)
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr, "non-JSON error body should still be an *Error") {
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+		assert.Equal(t, "<html><body>502 Bad Gateway</body></html>", apiErr.Body)
+		assert.Equal(t, "Bad Gateway (502)", apiErr.Error())
+	}
+	assert.True(t, IsServerError(err))
+
+	_, err = client.get(context.Background(), "/empty", nil, nil)
+	if assert.ErrorAs(t, err, &apiErr, "empty error body should still be an *Error") {
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		assert.Empty(t, apiErr.Body)
+		assert.Equal(t, "Service Unavailable (503)", apiErr.Error())
+	}
+}
+
+func TestError_FieldErrors(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		c.Data(http.StatusUnprocessableEntity, "application/json", []byte(`{"success":false,"error_code":422,"error_message":"validation failed","fields":{"name":"must not be empty","description":"too long"}}`))
+	})
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, newErrorResponse(http.StatusNotFound, errors.New("tenant not found")))
+	})
+
+	_, err := client.Tenant.Create(context.TODO(), &TenantCreateRequest{})
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, map[string]string{"name": "must not be empty", "description": "too long"}, apiErr.FieldErrors())
+		assert.Equal(t, "validation failed", apiErr.Response.ErrorMessage)
+		assert.Equal(t, "validation failed (422)", apiErr.Error())
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Response.ErrorCode)
+	}
+
+	_, err = client.Tenant.Get(context.TODO(), "ten_missing")
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Nil(t, apiErr.FieldErrors(), "errors without fields should have none")
+	}
+}
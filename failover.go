@@ -0,0 +1,19 @@
+package zerogate
+
+// baseURLKey carries the base URL a single attempt is sent to when failing
+// over.
+type baseURLKey struct{}
+
+// baseURLs returns the primary base URL followed by the failover URLs.
+func (c *Client) baseURLs() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]string{c.baseUrl}, c.failoverURLs...)
+}
+
+// shouldFailover reports whether the next attempt should go to the next base
+// URL, i.e. the current one could not be reached or failed with a server
+// error.
+func shouldFailover(err error) bool {
+	return IsServerError(err) || isTransientNetworkError(err)
+}
@@ -0,0 +1,85 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_FailoverPrimaryDown(t *testing.T) {
+	setup(WithRetry(2, time.Millisecond))
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+	assert.NoError(t, WithFailoverURLs(server.URL)(client))
+	client.baseUrl = "http://127.0.0.1:1"
+
+	res, err := client.get(context.Background(), "/tenants", nil, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, server.URL, res.BaseURL, "secondary should serve the response")
+	}
+}
+
+func TestClient_FailoverServerError(t *testing.T) {
+	setup(WithRetry(2, time.Millisecond))
+	defer teardown()
+	primaryCalls := 0
+	router.GET("/tenants", func(c *gin.Context) {
+		primaryCalls++
+		c.JSON(http.StatusInternalServerError, newErrorResponse(http.StatusInternalServerError, errors.New("internal error")))
+	})
+	secondaryCalls := 0
+	secondary := gin.New()
+	secondary.GET("/tenants", func(c *gin.Context) {
+		secondaryCalls++
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+	secondaryServer := httptest.NewServer(secondary)
+	defer secondaryServer.Close()
+	assert.NoError(t, WithFailoverURLs(secondaryServer.URL)(client))
+
+	res, err := client.get(context.Background(), "/tenants", nil, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, secondaryServer.URL, res.BaseURL)
+	}
+	assert.Equal(t, 1, primaryCalls)
+	assert.Equal(t, 1, secondaryCalls)
+
+	// every request starts at the primary again
+	res, err = client.get(context.Background(), "/tenants", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, primaryCalls)
+}
+
+func TestClient_FailoverRequiresRetries(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	assert.NoError(t, WithFailoverURLs(server.URL)(client))
+	client.baseUrl = "http://127.0.0.1:1"
+
+	_, err := client.get(context.Background(), "/tenants", nil, nil)
+	assert.Error(t, err, "without retries the request should not fail over")
+}
+
+func TestFailoverURLsOption(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret, WithFailoverURLs("https://eu.zerogate.test/public/v1/", "https://us.zerogate.test/public/v1"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"https://eu.zerogate.test/public/v1", "https://us.zerogate.test/public/v1"}, client.failoverURLs)
+		assert.Len(t, client.baseURLs(), 3)
+	}
+	_, err = New(testApiKey, testApiSecret, WithFailoverURLs("eu.zerogate.test"))
+	assert.Error(t, err, "invalid failover url should be rejected")
+}
@@ -0,0 +1,74 @@
+package zerogate
+
+import (
+	"context"
+)
+
+// PageFunc fetches the given 1-based page of a list endpoint and returns its
+// items along with the total number of items.
+type PageFunc[T any] func(ctx context.Context, page int) ([]T, int64, error)
+
+// Iterator iterates over the items of a paginated list endpoint, fetching
+// pages on demand.
+type Iterator[T any] struct {
+	fetch    PageFunc[T]
+	page     int
+	maxPages int
+	items    []T
+	index    int
+	seen     int64
+	current  T
+	done     bool
+	err      error
+}
+
+// NewIterator creates an iterator over the pages returned by fetch.
+func NewIterator[T any](fetch PageFunc[T]) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch}
+}
+
+// Next advances the iterator to the next item, fetching the next page when
+// needed. It returns false when there are no more items or an error occurred.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for it.index >= len(it.items) {
+		if it.done {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		it.page++
+		items, total, err := it.fetch(ctx, it.page)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if it.page == 1 && len(items) > 0 {
+			// guards against servers reporting an inconsistent total
+			it.maxPages = int((total + int64(len(items)) - 1) / int64(len(items)))
+		}
+		it.items = items
+		it.index = 0
+		it.seen += int64(len(items))
+		if len(items) == 0 || it.seen >= total || it.page >= it.maxPages {
+			it.done = true
+		}
+	}
+	it.current = it.items[it.index]
+	it.index++
+	return true
+}
+
+// Value returns the current item.
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
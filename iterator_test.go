@@ -0,0 +1,73 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7}
+	var pages []int
+	it := NewIterator(func(ctx context.Context, page int) ([]int, int64, error) {
+		pages = append(pages, page)
+		start := (page - 1) * 3
+		end := start + 3
+		if end > len(data) {
+			end = len(data)
+		}
+		return data[start:end], int64(len(data)), nil
+	})
+
+	var items []int
+	for it.Next(context.TODO()) {
+		items = append(items, it.Value())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, data, items, "all items should be iterated in order")
+	assert.Equal(t, []int{1, 2, 3}, pages, "pages should be fetched once each")
+	assert.False(t, it.Next(context.TODO()), "exhausted iterator should stay exhausted")
+}
+
+func TestIteratorError(t *testing.T) {
+	pageErr := errors.New("page failed")
+	it := NewIterator(func(ctx context.Context, page int) ([]int, int64, error) {
+		if page == 2 {
+			return nil, 0, pageErr
+		}
+		return []int{1, 2}, 4, nil
+	})
+
+	var items []int
+	for it.Next(context.TODO()) {
+		items = append(items, it.Value())
+	}
+	assert.ErrorIs(t, it.Err(), pageErr)
+	assert.Equal(t, []int{1, 2}, items, "items before the failing page should be iterated")
+}
+
+func TestIteratorEmpty(t *testing.T) {
+	it := NewIterator(func(ctx context.Context, page int) ([]int, int64, error) {
+		return nil, 0, nil
+	})
+	assert.False(t, it.Next(context.TODO()))
+	assert.NoError(t, it.Err())
+}
+
+func TestTenantService_ListIterator(t *testing.T) {
+	setup()
+	defer teardown()
+	tenants := testTenants(150)
+	router.GET("/tenants", servePages(t, tenants, int64(len(tenants))))
+
+	it := client.Tenant.ListIterator()
+	count := 0
+	for it.Next(context.TODO()) {
+		assert.Equal(t, tenants[count].Id, it.Value().Id)
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, len(tenants), count)
+}
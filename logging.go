@@ -0,0 +1,45 @@
+package zerogate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// logRequest emits a structured log record for a request attempt when a
+// slog logger is configured. Successful requests are logged at info level
+// and failures at error level.
+func (c *Client) logRequest(ctx context.Context, req *http.Request, nonce string, duration time.Duration, res *APIResponse, err error) {
+	if c.slogger == nil {
+		return
+	}
+	status := statusCode(err)
+	if res != nil {
+		status = res.StatusCode
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", duration),
+		slog.String("nonce", nonce),
+		slog.String("request_id", req.Header.Get(requestIDHeader)),
+		slog.Any("headers", redactHeaders(req.Header)),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.slogger.LogAttrs(ctx, level, "ZeroGate request", attrs...)
+}
+
+// redactHeaders returns a copy of the headers with credentials removed.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted.Get("Authorization") != "" {
+		redacted.Set("Authorization", "[REDACTED]")
+	}
+	return redacted
+}
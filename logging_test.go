@@ -0,0 +1,57 @@
+package zerogate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	setup(WithSlog(slog.New(slog.NewJSONHandler(&buf, nil))))
+	defer teardown()
+	router.GET("/logged", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.GET("/failed", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "not found"))
+	})
+
+	_, err := client.get(context.Background(), "/logged", nil, nil)
+	assert.NoError(t, err)
+	var record map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &record)) {
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "GET", record["method"])
+		assert.Equal(t, "/logged", record["path"])
+		assert.Equal(t, float64(http.StatusOK), record["status"])
+		assert.NotZero(t, record["nonce"])
+		assert.Contains(t, record, "duration")
+		headers := record["headers"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"[REDACTED]"}, headers["Authorization"])
+	}
+	assert.NotContains(t, buf.String(), testApiKey, "credentials should not be logged")
+
+	buf.Reset()
+	_, err = client.get(context.Background(), "/failed", nil, nil)
+	assert.Error(t, err)
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &record)) {
+		assert.Equal(t, "ERROR", record["level"])
+		assert.Equal(t, float64(http.StatusNotFound), record["status"])
+		assert.Contains(t, record, "error")
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{"Authorization": {"APIKey=key"}, "X-Test": {"value"}}
+	redacted := redactHeaders(header)
+	assert.Equal(t, "[REDACTED]", redacted.Get("Authorization"))
+	assert.Equal(t, "value", redacted.Get("X-Test"))
+	assert.Equal(t, "APIKey=key", header.Get("Authorization"), "original headers should not be modified")
+}
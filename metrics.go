@@ -0,0 +1,96 @@
+package zerogate
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics Prometheus collectors of the API calls, see WithMetrics
+type metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	inFlight prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "zerogate",
+			Name:      "requests_total",
+			Help:      "Number of completed ZeroGate API calls by method and status code.",
+		}, []string{"method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "zerogate",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of ZeroGate API calls including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "zerogate",
+			Name:      "request_retries_total",
+			Help:      "Number of retried ZeroGate API call attempts by method.",
+		}, []string{"method"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "zerogate",
+			Name:      "requests_in_flight",
+			Help:      "Number of ZeroGate API calls in flight.",
+		}),
+	}
+	var err error
+	// clients sharing a registerer share the collectors
+	if m.requests, err = register(reg, m.requests); err != nil {
+		return nil, err
+	}
+	if m.duration, err = register(reg, m.duration); err != nil {
+		return nil, err
+	}
+	if m.retries, err = register(reg, m.retries); err != nil {
+		return nil, err
+	}
+	if m.inFlight, err = register(reg, m.inFlight); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers the collector, returning the one already registered
+// under the same name instead.
+func register[C prometheus.Collector](reg prometheus.Registerer, collector C) (C, error) {
+	err := reg.Register(collector)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return collector, err
+}
+
+// start records the start of a call and returns the function recording its
+// completion.
+func (m *metrics) start(method string) func(*APIResponse, error) {
+	m.inFlight.Inc()
+	start := time.Now()
+	return func(res *APIResponse, err error) {
+		m.inFlight.Dec()
+		m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		status := "error"
+		if code := responseStatus(res, err); code != 0 {
+			status = strconv.Itoa(code)
+		}
+		m.requests.WithLabelValues(method, status).Inc()
+	}
+}
+
+// responseStatus returns the status code of a completed call, zero when no
+// response was received.
+func responseStatus(res *APIResponse, err error) int {
+	if res != nil {
+		return res.StatusCode
+	}
+	return statusCode(err)
+}
@@ -0,0 +1,131 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	setup(WithMetrics(reg), WithRetry(2, time.Millisecond))
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		assert.Equal(t, 1.0, testutil.ToFloat64(client.metrics.inFlight), "call should be in flight")
+		if c.Param("tenantId") == "ten_missing" {
+			c.JSON(http.StatusNotFound, newErrorResponse(http.StatusNotFound, errors.New("tenant not found")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+	attempts := 0
+	router.PUT("/tenants/:tenantId", func(c *gin.Context) {
+		attempts++
+		if attempts == 1 {
+			c.JSON(http.StatusServiceUnavailable, newErrorResponse(http.StatusServiceUnavailable, errors.New("unavailable")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	_, err := client.Tenant.Get(context.TODO(), "ten_1")
+	assert.NoError(t, err)
+	_, err = client.Tenant.Get(context.TODO(), "ten_1")
+	assert.NoError(t, err)
+	_, err = client.Tenant.Get(context.TODO(), "ten_missing")
+	assert.Error(t, err)
+	_, err = client.Tenant.Update(context.TODO(), "ten_1", &TenantUpdateRequest{Name: "Test"})
+	assert.NoError(t, err)
+
+	m := client.metrics
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodPut, "200")), "retried call should count once")
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.retries.WithLabelValues(http.MethodPut)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.inFlight), "no call should be in flight")
+	assert.Equal(t, 2, testutil.CollectAndCount(m.duration), "durations should be observed by method")
+
+	count, err := testutil.GatherAndCount(reg, "zerogate_requests_total", "zerogate_request_duration_seconds",
+		"zerogate_request_retries_total", "zerogate_requests_in_flight")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3+2+1+1, count, "metrics should be registered with the registry")
+	}
+}
+
+func TestWithMetricsSharedRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	first, err := New(testApiKey, testApiSecret, WithMetrics(reg))
+	if !assert.NoError(t, err) {
+		return
+	}
+	second, err := New(testApiKey, testApiSecret, WithMetrics(reg))
+	if assert.NoError(t, err, "clients should be able to share a registry") {
+		assert.Same(t, first.metrics.requests, second.metrics.requests)
+	}
+	_, err = New(testApiKey, testApiSecret, WithMetrics(nil))
+	assert.Error(t, err, "nil registerer should be rejected")
+
+	client, err := New(testApiKey, testApiSecret)
+	if assert.NoError(t, err) {
+		assert.Nil(t, client.metrics, "metrics should be disabled by default")
+	}
+}
+
+type observation struct {
+	methodPath string
+	status     int
+	dur        time.Duration
+	err        error
+}
+
+func TestWithObserver(t *testing.T) {
+	var observations []observation
+	setup(WithRetry(2, 20*time.Millisecond), WithObserver(func(methodPath string, status int, dur time.Duration, err error) {
+		observations = append(observations, observation{methodPath, status, dur, err})
+	}))
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		if c.Param("tenantId") == "ten_missing" {
+			c.JSON(http.StatusNotFound, newErrorResponse(http.StatusNotFound, errors.New("tenant not found")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+	attempts := 0
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		attempts++
+		if attempts == 1 {
+			c.JSON(http.StatusServiceUnavailable, newErrorResponse(http.StatusServiceUnavailable, errors.New("unavailable")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse("ok"))
+	})
+
+	_, err := client.Tenant.Get(context.TODO(), "ten_1")
+	assert.NoError(t, err)
+	_, err = client.Tenant.Get(context.TODO(), "ten_missing")
+	assert.Error(t, err)
+	assert.NoError(t, client.Tenant.Delete(context.TODO(), "ten_1"))
+
+	if !assert.Len(t, observations, 3, "each call should be observed once") {
+		return
+	}
+	assert.Equal(t, "GET /tenants/ten_1", observations[0].methodPath)
+	assert.Equal(t, http.StatusOK, observations[0].status)
+	assert.NoError(t, observations[0].err)
+
+	assert.Equal(t, "GET /tenants/ten_missing", observations[1].methodPath)
+	assert.Equal(t, http.StatusNotFound, observations[1].status)
+	assert.True(t, IsNotFound(observations[1].err), "failure should be observed with its error")
+
+	assert.Equal(t, "DELETE /tenants/ten_1", observations[2].methodPath)
+	assert.Equal(t, http.StatusOK, observations[2].status)
+	assert.GreaterOrEqual(t, observations[2].dur, 20*time.Millisecond, "duration should include the retry delay")
+}
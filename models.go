@@ -1,16 +1,24 @@
 package zerogate
 
 import (
-	"database/sql"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 )
 
 // Base common model
 type Base struct {
-	Id        string       `json:"id"`
-	Updated   int64        `json:"created"`
-	Created   int64        `json:"updated"`
-	DeletedAt sql.NullTime `json:"deleted_at"`
+	Id        string     `json:"id"`
+	Created   Timestamp  `json:"created"`
+	Updated   Timestamp  `json:"updated"`
+	DeletedAt *Timestamp `json:"deleted_at"`
+}
+
+// IsDeleted reports whether the resource has been soft deleted
+func (b *Base) IsDeleted() bool {
+	return b.DeletedAt != nil && !b.DeletedAt.IsZero()
 }
 
 // TenantBase tenant model
@@ -38,11 +46,97 @@ type SuccessPagingResponse[T any] struct {
 	Total   int64 `json:"total"`
 }
 
+// List list result
+type List[T any] struct {
+	Items    []T
+	Total    int64
+	PageInfo PageInfo
+}
+
+// PageInfo requested page of a list result, zero values mean the server
+// defaults were used
+type PageInfo struct {
+	Page     int
+	PageSize int
+}
+
+// Page a page of a list result along with the requested paging. A zero Page
+// is the first page, a zero PageSize the server default.
+type Page[T any] struct {
+	Items    []T
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// HasNext reports whether there are items after this page. When the page
+// size was left to the server, only the first page can tell: on later pages
+// a short last page cannot be told from a full one, so HasNext reports false
+// and PageSize must be set to page past the first.
+func (p *Page[T]) HasNext() bool {
+	if len(p.Items) == 0 {
+		return false
+	}
+	page := max(p.Page, 1)
+	if p.PageSize <= 0 {
+		return page == 1 && int64(len(p.Items)) < p.Total
+	}
+	return int64((page-1)*p.PageSize+len(p.Items)) < p.Total
+}
+
+// newPage returns the page of a list result.
+func newPage[T any](list *List[T]) *Page[T] {
+	return &Page[T]{
+		Items:    list.Items,
+		Total:    list.Total,
+		Page:     list.PageInfo.Page,
+		PageSize: list.PageInfo.PageSize,
+	}
+}
+
+// Sort orders of list requests
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// listQuery returns the query of a list request, leaving out zero values so
+// that the server defaults are used.
+func listQuery(page, pageSize int, sortBy, order string) map[string][]string {
+	query := make(map[string][]string)
+	if page > 0 {
+		query["page"] = []string{strconv.Itoa(page)}
+	}
+	if pageSize > 0 {
+		query["page_size"] = []string{strconv.Itoa(pageSize)}
+	}
+	if sortBy != "" {
+		query["sort_by"] = []string{sortBy}
+	}
+	if order != "" {
+		query["order"] = []string{order}
+	}
+	return query
+}
+
+// validateOrder checks the sort order of a list request, empty uses the
+// server default.
+func validateOrder(order string) error {
+	switch order {
+	case "", OrderAsc, OrderDesc:
+		return nil
+	}
+	return fmt.Errorf("invalid sort order %q, must be %q or %q", order, OrderAsc, OrderDesc)
+}
+
 // ErrorResponse error response
 type ErrorResponse struct {
 	ErrorCode    int    `json:"error_code"`
 	ErrorMessage string `json:"error_message"`
 	Success      bool   `json:"success"`
+	// Fields holds per-field messages of a validation failure keyed by field
+	// name, nil when the server sent none.
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 // APIResponse API response
@@ -51,6 +145,34 @@ type APIResponse struct {
 	Status     string
 	StatusCode int
 	Headers    http.Header
+
+	// RequestID is the X-Request-ID echoed by the server, or the one sent.
+	RequestID string
+	// BaseURL is the base URL that served the response, which differs from
+	// the configured one after a failover.
+	BaseURL string
+	// NotModified is set when the server answered a conditional request with
+	// 304 Not Modified, the body is then empty.
+	NotModified bool
+}
+
+// Into unmarshals the JSON body of the response into v.
+func (r *APIResponse) Into(v interface{}) error {
+	err := json.Unmarshal(r.Body, v)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return nil
+}
+
+// Header returns the first value of the response header, empty when absent.
+func (r *APIResponse) Header(key string) string {
+	return r.Headers.Get(key)
+}
+
+// empty reports whether the response carries no body, e.g. a 204 No Content.
+func (r *APIResponse) empty() bool {
+	return r.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(r.Body)) == 0
 }
 
 func newSuccessResponse[T any](data T) *SuccessResponse[T] {
@@ -0,0 +1,88 @@
+package zerogate
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase_UnmarshalTimestamps(t *testing.T) {
+	var base Base
+	err := json.Unmarshal([]byte(`{"id":"ten_ea87af463d9fc38203690805c1c1fa33","created":1600000000,"updated":1700000000}`), &base)
+	if err != nil {
+		assert.NoError(t, err, "base unmarshal error")
+		return
+	}
+	assert.Equal(t, "ten_ea87af463d9fc38203690805c1c1fa33", base.Id)
+	assert.Equal(t, int64(1600000000), base.Created.Unix(), "created timestamp is not equal")
+	assert.Equal(t, int64(1700000000), base.Updated.Unix(), "updated timestamp is not equal")
+}
+
+func TestBase_DeletedAt(t *testing.T) {
+	var base Base
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"ten_1","deleted_at":null}`), &base))
+	assert.Nil(t, base.DeletedAt)
+	assert.False(t, base.IsDeleted(), "null deleted_at should not be deleted")
+	data, err := json.Marshal(base)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"deleted_at":null`)
+
+	base = Base{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"ten_1","deleted_at":1700000000}`), &base))
+	if assert.NotNil(t, base.DeletedAt) {
+		assert.Equal(t, int64(1700000000), base.DeletedAt.Unix())
+	}
+	assert.True(t, base.IsDeleted(), "populated deleted_at should be deleted")
+	data, err = json.Marshal(base)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"deleted_at":1700000000`)
+
+	base = Base{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"ten_1","deleted_at":"2023-11-14T22:13:20Z"}`), &base))
+	assert.True(t, base.IsDeleted(), "RFC 3339 deleted_at should be deleted")
+}
+
+func TestAPIResponse_Into(t *testing.T) {
+	res := &APIResponse{
+		Body:    []byte(`{"success":true,"data":{"id":"ten_1","name":"Test"}}`),
+		Headers: http.Header{"Etag": {`"v1"`}},
+	}
+	var r SuccessResponse[*Tenant]
+	if assert.NoError(t, res.Into(&r)) {
+		assert.Equal(t, "ten_1", r.Data.Id)
+		assert.Equal(t, "Test", r.Data.Name)
+	}
+	assert.Equal(t, `"v1"`, res.Header("ETag"), "header lookup should be case insensitive")
+	assert.Empty(t, res.Header("X-Missing"))
+
+	res.Body = []byte(`{"success":true,"data":`)
+	assert.Error(t, res.Into(&r), "malformed JSON should fail")
+	res.Body = []byte(`{"success":true,"data":"not a tenant"}`)
+	assert.Error(t, res.Into(&r), "mismatched JSON should fail")
+}
+
+func TestPage_HasNext(t *testing.T) {
+	items := func(n int) []int { return make([]int, n) }
+	tests := []struct {
+		name string
+		page Page[int]
+		want bool
+	}{
+		{"first of several", Page[int]{Items: items(10), Total: 25, Page: 1, PageSize: 10}, true},
+		{"before last", Page[int]{Items: items(10), Total: 25, Page: 2, PageSize: 10}, true},
+		{"partial last", Page[int]{Items: items(5), Total: 25, Page: 3, PageSize: 10}, false},
+		{"full last", Page[int]{Items: items(10), Total: 30, Page: 3, PageSize: 10}, false},
+		{"one item left", Page[int]{Items: items(10), Total: 31, Page: 3, PageSize: 10}, true},
+		{"beyond last", Page[int]{Items: nil, Total: 30, Page: 4, PageSize: 10}, false},
+		{"zero page is first", Page[int]{Items: items(10), Total: 11, PageSize: 10}, true},
+		{"server page size", Page[int]{Items: items(20), Total: 50}, true},
+		{"server page size all", Page[int]{Items: items(20), Total: 20}, false},
+		{"server page size short last", Page[int]{Items: items(5), Total: 25, Page: 3}, false},
+		{"server page size later page", Page[int]{Items: items(10), Total: 50, Page: 2}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.page.HasNext(), tt.name)
+	}
+}
@@ -0,0 +1,62 @@
+package zerogate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sort"
+)
+
+// MultipartBody a multipart/form-data request body, e.g. for certificate or
+// config uploads. Passed as the body of Do it is encoded in full, sent with
+// the boundary Content-Type and signed over the encoded bytes.
+type MultipartBody struct {
+	// Fields plain form fields by name
+	Fields map[string]string
+	// Files files to upload by form field name
+	Files map[string]MultipartFile
+}
+
+// MultipartFile a file part of a MultipartBody
+type MultipartFile struct {
+	Filename string
+	Content  io.Reader
+}
+
+// encode returns the encoded body and its Content-Type. Parts are written in
+// name order, fields before files.
+func (m *MultipartBody) encode() ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, name := range sortedKeys(m.Fields) {
+		if err := w.WriteField(name, m.Fields[name]); err != nil {
+			return nil, "", fmt.Errorf("error writing multipart field %q: %w", name, err)
+		}
+	}
+	for _, name := range sortedKeys(m.Files) {
+		file := m.Files[name]
+		part, err := w.CreateFormFile(name, file.Filename)
+		if err != nil {
+			return nil, "", fmt.Errorf("error writing multipart file %q: %w", name, err)
+		}
+		if file.Content != nil {
+			if _, err := io.Copy(part, file.Content); err != nil {
+				return nil, "", fmt.Errorf("error reading multipart file %q: %w", name, err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("error writing multipart body: %w", err)
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package zerogate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_MultipartSingleFile(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/certificates", func(c *gin.Context) {
+		assert.True(t, strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data; boundary="))
+		testSignature(c, t)
+		header, err := c.FormFile("certificate")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "cert.pem", header.Filename)
+		f, err := header.Open()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		assert.Equal(t, "-----BEGIN CERTIFICATE-----", string(content))
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	body := &MultipartBody{Files: map[string]MultipartFile{
+		"certificate": {Filename: "cert.pem", Content: strings.NewReader("-----BEGIN CERTIFICATE-----")},
+	}}
+	_, err := client.Do(context.Background(), http.MethodPost, "/certificates", nil, body)
+	assert.NoError(t, err)
+}
+
+func TestClient_MultipartFields(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/configs", func(c *gin.Context) {
+		testSignature(c, t)
+		form, err := c.MultipartForm()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"edge"}, form.Value["name"])
+		assert.Equal(t, []string{"yaml"}, form.Value["format"])
+		if assert.Len(t, form.File["config"], 1) && assert.Len(t, form.File["schema"], 1) {
+			assert.Equal(t, "edge.yaml", form.File["config"][0].Filename)
+			assert.Equal(t, "schema.json", form.File["schema"][0].Filename)
+			f, _ := form.File["schema"][0].Open()
+			content, _ := io.ReadAll(f)
+			f.Close()
+			assert.Equal(t, `{"type":"object"}`, string(content))
+		}
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	body := MultipartBody{
+		Fields: map[string]string{"name": "edge", "format": "yaml"},
+		Files: map[string]MultipartFile{
+			"config": {Filename: "edge.yaml", Content: strings.NewReader("listen: 443")},
+			"schema": {Filename: "schema.json", Content: strings.NewReader(`{"type":"object"}`)},
+		},
+	}
+	_, err := client.Do(context.Background(), http.MethodPost, "/configs", nil, body)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,105 @@
+package zerogate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NonceGenerator generates the nonce of a request signature from the signing
+// timestamp in Unix seconds, or milliseconds with WithNonceResolution. Nonces
+// must be unique per API key, the server rejects a nonce it has already seen.
+type NonceGenerator interface {
+	Nonce(timestamp int64) string
+}
+
+// randomNonceGenerator generates "<timestamp>.<random hex>" nonces, so that
+// requests signed within the same second do not collide.
+type randomNonceGenerator struct{}
+
+func (randomNonceGenerator) Nonce(timestamp int64) string {
+	return fmt.Sprintf("%d.%s", timestamp, hex.EncodeToString(randomBytes(8)))
+}
+
+// randomBytes returns n bytes read from crypto/rand.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the OS entropy source is unavailable
+		panic(fmt.Sprintf("zerogate: reading random bytes: %v", err))
+	}
+	return b
+}
+
+// nonceTimestamp returns the timestamp part of a nonce.
+func nonceTimestamp(nonce string) (int64, error) {
+	value, _, _ := strings.Cut(nonce, ".")
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid nonce %q: %w", nonce, err)
+	}
+	return timestamp, nil
+}
+
+// NonceExpiry returns the time at which a signature using a nonce with the
+// given timestamp, in Unix seconds or milliseconds, falls outside the
+// server's validity window.
+func NonceExpiry(nonce int64, window time.Duration) time.Time {
+	return nonceTime(nonce).Add(window)
+}
+
+// nonceTime converts a nonce timestamp to a time. Timestamps too large to be
+// Unix seconds in any realistic date are taken as Unix milliseconds.
+func nonceTime(timestamp int64) time.Time {
+	if timestamp >= 1e12 {
+		return time.UnixMilli(timestamp)
+	}
+	return time.Unix(timestamp, 0)
+}
+
+// RequestNonce returns the timestamp of the nonce of a request signed by the
+// client.
+func RequestNonce(req *http.Request) (int64, error) {
+	params, err := parseAuthorization(req.Header.Get("Authorization"))
+	if err != nil {
+		return 0, err
+	}
+	value, ok := params["Nonce"]
+	if !ok {
+		return 0, errors.New("authorization header has no nonce")
+	}
+	return nonceTimestamp(value)
+}
+
+// NonceRemaining returns how long the signature of a prepared request remains
+// valid for the given window, negative once it has expired. Use Resign to
+// refresh an expired request.
+func NonceRemaining(req *http.Request, window time.Duration) (time.Duration, error) {
+	nonce, err := RequestNonce(req)
+	if err != nil {
+		return 0, err
+	}
+	return time.Until(NonceExpiry(nonce, window)), nil
+}
+
+// parseAuthorization parses the "Key=Value, Key=Value" parameters of an
+// Authorization header.
+func parseAuthorization(header string) (map[string]string, error) {
+	if header == "" {
+		return nil, errors.New("empty authorization header")
+	}
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("malformed authorization parameter %q", part)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
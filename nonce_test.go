@@ -0,0 +1,139 @@
+package zerogate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceExpiry(t *testing.T) {
+	nonce := int64(1700000000)
+	window := 5 * time.Minute
+	expiry := NonceExpiry(nonce, window)
+	assert.Equal(t, time.Unix(nonce, 0).Add(window), expiry)
+	assert.True(t, time.Unix(nonce+299, 0).Before(expiry), "nonce should be valid just inside the window")
+	assert.False(t, time.Unix(nonce+300, 0).Before(expiry), "nonce should expire at the window boundary")
+}
+
+func TestNonceRemaining(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret)
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/tenants", nil)
+	assert.NoError(t, client.Resign(req))
+
+	nonce, err := RequestNonce(req)
+	if assert.NoError(t, err) {
+		assert.InDelta(t, time.Now().Unix(), nonce, 1)
+	}
+	remaining, err := NonceRemaining(req, time.Minute)
+	if assert.NoError(t, err) {
+		assert.True(t, remaining > 58*time.Second && remaining <= time.Minute, "remaining %s should be within the window", remaining)
+	}
+
+	req.Header.Set("Authorization", "APIKey=key, Signature=abc, Nonce=1")
+	remaining, err = NonceRemaining(req, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Negative(t, remaining, "stale nonce should be expired")
+	}
+}
+
+func TestRequestNonceMalformed(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/tenants", nil)
+	_, err := RequestNonce(req)
+	assert.Error(t, err, "missing header should fail")
+
+	for _, header := range []string{"APIKey=key, Signature=abc", "APIKey=key, Nonce=soon", "APIKey=key, Nonce=.abc", "garbage"} {
+		req.Header.Set("Authorization", header)
+		_, err = RequestNonce(req)
+		assert.Error(t, err, "header %q should fail", header)
+	}
+}
+
+func TestRandomNonceGenerator(t *testing.T) {
+	// nonces of requests signed in the same second must not collide
+	const workers, perWorker = 8, 250
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{}, workers*perWorker)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				nonce := randomNonceGenerator{}.Nonce(1700000000)
+				mutex.Lock()
+				seen[nonce] = struct{}{}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, workers*perWorker, "nonces should be unique")
+
+	timestamp, err := nonceTimestamp(randomNonceGenerator{}.Nonce(1700000000))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1700000000), timestamp)
+	}
+}
+
+type fixedNonceGenerator string
+
+func (g fixedNonceGenerator) Nonce(timestamp int64) string {
+	return fmt.Sprintf("%d.%s", timestamp, string(g))
+}
+
+func TestNonceGeneratorOption(t *testing.T) {
+	setup(WithNonceGenerator(fixedNonceGenerator("fixed")))
+	defer teardown()
+	router.GET("/nonce", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+	var header string
+	client.requestHooks = append(client.requestHooks, func(req *http.Request) {
+		header = req.Header.Get("Authorization")
+	})
+
+	_, err := client.get(context.Background(), "/nonce", nil, nil)
+	assert.NoError(t, err)
+	assert.Regexp(t, `, Nonce=\d+\.fixed$`, header)
+
+	_, err = New(testApiKey, testApiSecret, WithNonceGenerator(nil))
+	assert.Error(t, err, "nil nonce generator should be rejected")
+}
+
+func TestNonceResolutionOption(t *testing.T) {
+	setup(WithNonceResolution(time.Millisecond))
+	defer teardown()
+	var nonce string
+	router.GET("/nonce", func(c *gin.Context) {
+		testSignature(c, t)
+		var apiKey, signature string
+		n, err := fmt.Sscanf(c.Request.Header.Get("Authorization"), "APIKey=%s Signature=%s Nonce=%s", &apiKey, &signature, &nonce)
+		assert.NoError(t, err, "Authorization header should parse with Sscanf")
+		assert.Equal(t, 3, n)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	before := time.Now().UnixMilli()
+	_, err := client.get(context.Background(), "/nonce", nil, nil)
+	assert.NoError(t, err)
+	timestamp, err := nonceTimestamp(nonce)
+	if assert.NoError(t, err) {
+		assert.GreaterOrEqual(t, timestamp, before, "nonce should be in milliseconds")
+		assert.LessOrEqual(t, timestamp, time.Now().UnixMilli())
+	}
+	assert.Equal(t, time.UnixMilli(timestamp).Add(time.Minute), NonceExpiry(timestamp, time.Minute))
+
+	_, err = New(testApiKey, testApiSecret, WithNonceResolution(time.Microsecond))
+	assert.Error(t, err, "unsupported resolution should be rejected")
+}
@@ -1,7 +1,21 @@
 package zerogate
 
 import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 )
 
 // Option is a functional option for configuring the API client.
@@ -15,10 +29,80 @@ func HTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithDoer sends API calls with doer instead of the HTTP client, e.g. a fake
+// returning canned responses in tests. It cannot be combined with
+// WithSharedTransport, WithProxy, WithTLSConfig or WithMiddleware, which
+// configure the transport of the HTTP client.
+func WithDoer(doer Doer) Option {
+	return func(client *Client) error {
+		if doer == nil {
+			return fmt.Errorf("doer must not be nil")
+		}
+		client.doer = doer
+		return nil
+	}
+}
+
 // BaseURL allows you to override the default HTTP base URL used for API calls.
+// The URL must be an absolute http or https URL, a trailing slash is removed
+// as endpoints start with one.
 func BaseURL(baseURL string) Option {
 	return func(client *Client) error {
-		client.baseUrl = baseURL
+		normalized, err := normalizeBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+		client.baseUrl = normalized
+		return nil
+	}
+}
+
+// normalizeBaseURL validates an http or https base URL and strips any
+// trailing slash.
+func normalizeBaseURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("base url %q must use http or https", baseURL)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("base url %q has no host", baseURL)
+	}
+	return strings.TrimRight(baseURL, "/"), nil
+}
+
+// WithFailoverURLs sets base URLs to fail over to, in order, when a request
+// to the current one fails with a connection error or a server error. As
+// failing over happens on retry, it requires retries to be enabled with
+// WithRetry. Every request starts at the primary base URL and
+// APIResponse.BaseURL reports which one served it.
+func WithFailoverURLs(urls ...string) Option {
+	return func(client *Client) error {
+		failoverURLs := make([]string, 0, len(urls))
+		for _, u := range urls {
+			normalized, err := normalizeBaseURL(u)
+			if err != nil {
+				return fmt.Errorf("invalid failover url: %w", err)
+			}
+			failoverURLs = append(failoverURLs, normalized)
+		}
+		client.failoverURLs = failoverURLs
+		return nil
+	}
+}
+
+// WithBearerToken authenticates requests with "Authorization: Bearer <token>"
+// instead of the HMAC signature, for deployments issuing bearer tokens. The
+// client must then be created without an API key and secret, e.g.
+// New("", "", WithBearerToken(token)).
+func WithBearerToken(token string) Option {
+	return func(client *Client) error {
+		if token == "" {
+			return fmt.Errorf("bearer token must not be empty")
+		}
+		client.bearerToken = token
 		return nil
 	}
 }
@@ -31,6 +115,533 @@ func Debug(debug bool) Option {
 	}
 }
 
+// WithDebugWriter writes the request and response dumps of Debug mode to w
+// instead of the logger.
+func WithDebugWriter(w io.Writer) Option {
+	return func(client *Client) error {
+		if w == nil {
+			return fmt.Errorf("debug writer must not be nil")
+		}
+		client.debugWriter = w
+		return nil
+	}
+}
+
+// WithDebugBodyLimit sets the number of body bytes kept in the request and
+// response dumps of Debug mode, 64 KiB by default. Longer bodies are
+// truncated with a "...[truncated N bytes]" marker.
+func WithDebugBodyLimit(n int) Option {
+	return func(client *Client) error {
+		if n < 0 {
+			return fmt.Errorf("debug body limit must not be negative")
+		}
+		client.debugBodyLimit = n
+		return nil
+	}
+}
+
+// WithLogger sets the logger used for debug output, logging is silent when
+// no logger is supplied and debugging is disabled.
+func WithLogger(logger *log.Logger) Option {
+	return func(client *Client) error {
+		if logger == nil {
+			return fmt.Errorf("logger must not be nil")
+		}
+		client.logger = logger
+		return nil
+	}
+}
+
+// WithSlog emits a structured log record for every request, independently of
+// the Debug option.
+func WithSlog(logger *slog.Logger) Option {
+	return func(client *Client) error {
+		if logger == nil {
+			return fmt.Errorf("slog logger must not be nil")
+		}
+		client.slogger = logger
+		return nil
+	}
+}
+
+// WithUserAgent prefixes the default User-Agent with ua, e.g. "myapp/1.0".
+func WithUserAgent(ua string) Option {
+	return func(client *Client) error {
+		ua = strings.TrimSpace(ua)
+		if ua == "" {
+			return fmt.Errorf("user agent must not be empty")
+		}
+		client.userAgent = ua + " " + userAgent
+		return nil
+	}
+}
+
+// reservedHeaders are set by the client on every request and can't be
+// overridden through default headers.
+var reservedHeaders = []string{"Authorization", "Content-Type", "User-Agent"}
+
+// WithHeader adds a default header sent with every request. Per-request
+// headers with the same key take precedence.
+func WithHeader(key, value string) Option {
+	return func(client *Client) error {
+		key = http.CanonicalHeaderKey(key)
+		for _, reserved := range reservedHeaders {
+			if key == reserved {
+				return fmt.Errorf("header %q is managed by the client", key)
+			}
+		}
+		client.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithHeaders adds default headers sent with every request.
+func WithHeaders(headers http.Header) Option {
+	return func(client *Client) error {
+		for key, values := range headers {
+			for _, value := range values {
+				if err := WithHeader(key, value)(client); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// WithPrefer sets the Prefer header sent with every request, e.g.
+// PreferMinimal. Use WithCallPrefer to override it for a single call.
+func WithPrefer(value string) Option {
+	return func(client *Client) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("prefer value must not be empty")
+		}
+		client.prefer = value
+		return nil
+	}
+}
+
+// WithSharedTransport sends requests through rt, which may be shared by many
+// clients to reuse a single connection pool. Credentials and signing stay
+// per client, and middlewares are applied on top of the shared transport.
+// WithProxy and WithTLSConfig cannot be combined with it, as they would
+// require a copy of rt with a pool of its own.
+func WithSharedTransport(rt http.RoundTripper) Option {
+	return func(client *Client) error {
+		if rt == nil {
+			return fmt.Errorf("shared transport must not be nil")
+		}
+		client.sharedTransport = rt
+		return nil
+	}
+}
+
+// WithProxy routes requests through the HTTP or HTTPS proxy at proxyURL, e.g.
+// "http://proxy.internal:3128". The transport of a client supplied with
+// HTTPClient is cloned rather than modified, and must be an *http.Transport.
+func WithProxy(proxyURL string) Option {
+	return func(client *Client) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+			return fmt.Errorf("proxy url %q must use http, https or socks5", proxyURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("proxy url %q has no host", proxyURL)
+		}
+		client.proxy = u
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the transport, e.g. to trust a
+// custom CA. The transport of a client supplied with HTTPClient is cloned
+// rather than modified, and must be an *http.Transport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(client *Client) error {
+		if config == nil {
+			return fmt.Errorf("TLS config must not be nil")
+		}
+		client.tlsClientConfig = config
+		return nil
+	}
+}
+
+// WithMiddleware wraps the transport of the HTTP client. Middlewares are
+// applied in order, the first one being the outermost, and see requests after
+// they have been signed.
+func WithMiddleware(middleware Middleware) Option {
+	return func(client *Client) error {
+		if middleware == nil {
+			return fmt.Errorf("middleware must not be nil")
+		}
+		client.middlewares = append(client.middlewares, middleware)
+		return nil
+	}
+}
+
+// WithRequestHook registers a hook called with every signed request just
+// before it is sent.
+func WithRequestHook(hook func(*http.Request)) Option {
+	return func(client *Client) error {
+		if hook == nil {
+			return fmt.Errorf("request hook must not be nil")
+		}
+		client.requestHooks = append(client.requestHooks, hook)
+		return nil
+	}
+}
+
+// WithResponseHook registers a hook called after every request with the
+// response and its latency. The response is nil when the request failed, and
+// its body must not be consumed by the hook.
+func WithResponseHook(hook func(*http.Response, time.Duration)) Option {
+	return func(client *Client) error {
+		if hook == nil {
+			return fmt.Errorf("response hook must not be nil")
+		}
+		client.responseHooks = append(client.responseHooks, hook)
+		return nil
+	}
+}
+
+// WithTracerProvider starts an OpenTelemetry client span for every request
+// and propagates the trace context to the server.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(client *Client) error {
+		if tp == nil {
+			return fmt.Errorf("tracer provider must not be nil")
+		}
+		client.tracer = tp.Tracer(tracerName)
+		return nil
+	}
+}
+
+// WithIdempotentDelete treats a 404 Not Found response to a delete as success,
+// for the service methods and Client.Do alike.
+func WithIdempotentDelete() Option {
+	return func(client *Client) error {
+		client.idempotentDelete = true
+		return nil
+	}
+}
+
+// WithAcceptFallback resends a request once with "Accept: */*" when the
+// server answers 406 Not Acceptable to "Accept: application/json", which
+// some misconfigured gateways do.
+func WithAcceptFallback() Option {
+	return func(client *Client) error {
+		client.acceptFallback = true
+		return nil
+	}
+}
+
+// WithMetrics registers Prometheus metrics of the API calls with reg: the
+// zerogate_requests_total counter by method and status, the
+// zerogate_request_duration_seconds histogram by method, the
+// zerogate_request_retries_total counter by method and the
+// zerogate_requests_in_flight gauge. Clients sharing reg share the metrics.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(client *Client) error {
+		if reg == nil {
+			return fmt.Errorf("metrics registerer must not be nil")
+		}
+		m, err := newMetrics(reg)
+		if err != nil {
+			return fmt.Errorf("metrics registration failed: %w", err)
+		}
+		client.metrics = m
+		return nil
+	}
+}
+
+// WithObserver registers fn to be called once per completed API call, after
+// any retries, with the method and endpoint, e.g. "GET /tenants", the status
+// code, the total duration and the error. The status is zero when no
+// response was received. A lightweight alternative to WithMetrics.
+func WithObserver(fn func(methodPath string, status int, dur time.Duration, err error)) Option {
+	return func(client *Client) error {
+		if fn == nil {
+			return fmt.Errorf("observer must not be nil")
+		}
+		client.observer = fn
+		return nil
+	}
+}
+
+// WithStrictDecoding makes decoding a response fail on fields the models do
+// not know, e.g. to catch API schema drift in tests, instead of dropping them.
+func WithStrictDecoding() Option {
+	return func(client *Client) error {
+		client.strictDecoding = true
+		return nil
+	}
+}
+
+// WithBodyTransformer rewrites every request body after it has been marshaled
+// and before it is compressed and signed, so the transformed body is what
+// gets signed and sent. The transformer must return valid JSON.
+func WithBodyTransformer(fn func(body []byte) ([]byte, error)) Option {
+	return func(client *Client) error {
+		if fn == nil {
+			return fmt.Errorf("body transformer must not be nil")
+		}
+		client.bodyTransformer = fn
+		return nil
+	}
+}
+
+// WithRequestCompression gzip compresses request bodies larger than 1 KiB and
+// sends them with "Content-Encoding: gzip". The signature covers the
+// compressed bytes.
+func WithRequestCompression() Option {
+	return func(client *Client) error {
+		client.compressRequests = true
+		return nil
+	}
+}
+
+// WithResponseCache caches up to maxEntries GET responses carrying an ETag.
+// Subsequent requests for the same path and query send If-None-Match, and a
+// 304 Not Modified response is answered with the cached body. Weak and strong
+// ETags of the same value match, so a tag weakened by a proxy still hits.
+func WithResponseCache(maxEntries int) Option {
+	return func(client *Client) error {
+		if maxEntries < 1 {
+			return fmt.Errorf("response cache size must be at least 1")
+		}
+		client.cache = newResponseCache(maxEntries)
+		return nil
+	}
+}
+
+// WithTimeout bounds requests made with a context without a deadline,
+// including their retries, e.g. when the HTTP client has no timeout.
+// Endpoint timeouts take precedence.
+func WithTimeout(d time.Duration) Option {
+	return func(client *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive")
+		}
+		client.timeout = d
+		return nil
+	}
+}
+
+// WithEndpointTimeouts sets request timeouts for endpoints matching the given
+// path patterns (see path.Match), e.g. "/tenants/*/export".
+func WithEndpointTimeouts(timeouts map[string]time.Duration) Option {
+	return func(client *Client) error {
+		if client.endpointTimeouts == nil {
+			client.endpointTimeouts = make(map[string]time.Duration, len(timeouts))
+		}
+		for pattern, timeout := range timeouts {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid endpoint pattern %q: %w", pattern, err)
+			}
+			if timeout <= 0 {
+				return fmt.Errorf("timeout for endpoint pattern %q must be positive", pattern)
+			}
+			client.endpointTimeouts[pattern] = timeout
+		}
+		return nil
+	}
+}
+
+// WithRetry retries idempotent requests failing with a rate limit or server
+// error up to maxRetries times, waiting baseDelay doubled after each attempt.
+// POST requests are sent with a generated Idempotency-Key so that they can be
+// retried without creating duplicates.
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(client *Client) error {
+		if maxRetries < 0 {
+			return fmt.Errorf("max retries must not be negative")
+		}
+		if baseDelay < 0 {
+			return fmt.Errorf("retry base delay must not be negative")
+		}
+		client.retry.MaxRetries = maxRetries
+		client.retry.BaseDelay = baseDelay
+		return nil
+	}
+}
+
+// WithRetryPredicate retries attempts for which fn returns true, in addition
+// to those retried by default. fn receives the response of a successful
+// attempt, or the error of a failed one, e.g. an *Error carrying an
+// application error code. It is only consulted when retries are enabled with
+// WithRetry or WithCallRetry, and never beyond the maximum number of retries.
+// Like the default retries, it never re-sends a PATCH, or a POST without an
+// Idempotency-Key, as that could apply the request twice.
+func WithRetryPredicate(fn func(resp *APIResponse, err error) bool) Option {
+	return func(client *Client) error {
+		if fn == nil {
+			return fmt.Errorf("retry predicate must not be nil")
+		}
+		client.retryPredicate = fn
+		return nil
+	}
+}
+
+// WithBackoff sets the strategy computing the delay between retries enabled
+// with WithRetry, replacing the exponential backoff from its base delay.
+func WithBackoff(backoff Backoff) Option {
+	return func(client *Client) error {
+		if backoff == nil {
+			return fmt.Errorf("backoff must not be nil")
+		}
+		client.retry.Backoff = backoff
+		return nil
+	}
+}
+
+// WithRateLimit limits the client to rps requests per second with bursts of up
+// to burst requests. Every attempt, including retries, waits for the limiter.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(client *Client) error {
+		if rps <= 0 {
+			return fmt.Errorf("rate limit must be positive")
+		}
+		if burst < 1 {
+			return fmt.Errorf("rate limit burst must be at least 1")
+		}
+		client.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		return nil
+	}
+}
+
+// WithVerifyResponseDigest verifies response bodies against the Content-MD5 or
+// Digest header when the server sends one, failing with ErrDigestMismatch.
+// The digest of a gzip response is verified over the compressed bytes, so
+// the client requests gzip itself rather than letting the transport
+// decompress responses it could no longer verify.
+func WithVerifyResponseDigest() Option {
+	return func(client *Client) error {
+		client.verifyDigest = true
+		return nil
+	}
+}
+
+// WithClockSkewTolerance sets the clock skew beyond which CheckClockSkew
+// returns ErrClockSkew, 30 seconds by default. Set it to the nonce window of
+// the server.
+func WithClockSkewTolerance(d time.Duration) Option {
+	return func(client *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("clock skew tolerance must be positive")
+		}
+		client.clockSkewTolerance = d
+		return nil
+	}
+}
+
+// WithPanicRecovery recovers from panics of the HTTP transport and of the
+// request and response hooks, failing the call with an error carrying the
+// panic value and stack instead of crashing the calling goroutine. Panics
+// propagate by default so that bugs are not hidden.
+func WithPanicRecovery() Option {
+	return func(client *Client) error {
+		client.panicRecovery = true
+		return nil
+	}
+}
+
+// WithMaxRequestSize limits encoded request bodies to n bytes, failing calls
+// with a larger body with ErrRequestTooLarge before they are signed and sent.
+// Rejected calls are logged to the logger. The limit applies to the body as
+// returned by the body transformer, before compression.
+func WithMaxRequestSize(n int64) Option {
+	return func(client *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max request size must be positive")
+		}
+		client.maxRequestSize = n
+		return nil
+	}
+}
+
+// WithMaxResponseSize limits response bodies to n bytes, failing calls with a
+// larger response with ErrResponseTooLarge instead of reading it into memory.
+// Response bodies are unlimited by default. Streamed response bodies of
+// GetStream are not limited.
+func WithMaxResponseSize(n int64) Option {
+	return func(client *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max response size must be positive")
+		}
+		client.maxResponseSize = n
+		return nil
+	}
+}
+
+// WithGlobalBufferLimit caps the request body bytes held in memory across all
+// concurrent requests of the client. Requests wait, or fail when ctx is done,
+// until enough of the limit is free for their body; a body larger than the
+// limit waits for the whole limit. As the size of a body is only known once
+// it is encoded, the limit is acquired after encoding: it bounds the bodies
+// held while requests are compressed, sent and retried, but not the encoding
+// of bodies still waiting for the limit.
+func WithGlobalBufferLimit(bytes int64) Option {
+	return func(client *Client) error {
+		if bytes <= 0 {
+			return fmt.Errorf("global buffer limit must be positive")
+		}
+		client.bufferLimit = bytes
+		client.bufferSem = semaphore.NewWeighted(bytes)
+		return nil
+	}
+}
+
+// WithNonceGenerator replaces the generator of request signature nonces,
+// e.g. with a deterministic one in tests.
+func WithNonceGenerator(generator NonceGenerator) Option {
+	return func(client *Client) error {
+		if generator == nil {
+			return fmt.Errorf("nonce generator must not be nil")
+		}
+		client.nonceGenerator = generator
+		return nil
+	}
+}
+
+// WithNonceResolution sets the precision of the nonce timestamp to
+// time.Second (the default) or time.Millisecond. With millisecond resolution
+// the nonce timestamp, and so the signed message, is in Unix milliseconds,
+// which the server must be configured to expect for the API key.
+func WithNonceResolution(resolution time.Duration) Option {
+	return func(client *Client) error {
+		if resolution != time.Second && resolution != time.Millisecond {
+			return fmt.Errorf("nonce resolution must be time.Second or time.Millisecond, got %s", resolution)
+		}
+		client.nonceResolution = resolution
+		return nil
+	}
+}
+
+// WithSignatureAlgorithm sets the HMAC algorithm requests are signed with,
+// HMAC-SHA512 by default. Other algorithms are announced to the server with
+// an Algorithm parameter in the Authorization header.
+func WithSignatureAlgorithm(algorithm SignatureAlgorithm) Option {
+	return func(client *Client) error {
+		if algorithm.hash() == nil {
+			return fmt.Errorf("unsupported signature algorithm %q", algorithm)
+		}
+		client.signature = algorithm
+		return nil
+	}
+}
+
+// withClock replaces the time source used for signing nonces and Retry-After
+// dates, for deterministic tests.
+func withClock(clock func() time.Time) Option {
+	return func(client *Client) error {
+		client.clock = clock
+		return nil
+	}
+}
+
 // parseOptions parses the supplied options functions and returns a configured
 // *Client instance.
 func (c *Client) parseOptions(options ...Option) error {
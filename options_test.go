@@ -1,8 +1,20 @@
 package zerogate
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -37,4 +49,527 @@ func TestBaseUrlOption(t *testing.T) {
 		assert.Error(t, nil, "client creation failed")
 	}
 	assert.Equal(t, client.baseUrl, testBaseUrl, "base url is not equal")
+
+	client, err = New(testApiKey, testApiSecret, BaseURL(testBaseUrl+"/"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, testBaseUrl, client.baseUrl, "trailing slash should be stripped")
+	}
+	for _, invalid := range []string{"ftp://localhost/public/v1", "localhost:8080/public/v1", "http://", "http://local host/v1"} {
+		_, err = New(testApiKey, testApiSecret, BaseURL(invalid))
+		assert.Error(t, err, "base url %q should be rejected", invalid)
+	}
+}
+
+func TestBaseUrlTrailingSlash(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		assert.Equal(t, "/tenants", c.Request.URL.Path)
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+	assert.NoError(t, BaseURL(server.URL+"/")(client))
+
+	_, err := client.get(context.Background(), "/tenants", nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestEndpointTimeoutsOption(t *testing.T) {
+	setup(WithEndpointTimeouts(map[string]time.Duration{
+		"/tenants":          100 * time.Millisecond,
+		"/tenants/*/export": 2 * time.Second,
+	}))
+	defer teardown()
+	handler := func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.JSON(http.StatusOK, "ok")
+	}
+	router.GET("/tenants", handler)
+	router.GET("/tenants/:tenantId/export", handler)
+
+	_, err := client.get(context.Background(), "/tenants", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "list endpoint should time out")
+
+	_, err = client.get(context.Background(), "/tenants/ten_ea87af463d9fc38203690805c1c1fa33/export", nil, nil)
+	assert.NoError(t, err, "export endpoint should use the longer timeout")
+
+	_, err = New(testApiKey, testApiSecret, WithEndpointTimeouts(map[string]time.Duration{"/tenants": 0}))
+	assert.Error(t, err, "non positive timeout should be rejected")
+	_, err = New(testApiKey, testApiSecret, WithEndpointTimeouts(map[string]time.Duration{"/tenants/[": time.Second}))
+	assert.Error(t, err, "invalid pattern should be rejected")
+}
+
+func TestTimeoutOption(t *testing.T) {
+	setup(WithTimeout(100*time.Millisecond), WithEndpointTimeouts(map[string]time.Duration{"/export": time.Second}))
+	defer teardown()
+	handler := func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.JSON(http.StatusOK, "ok")
+	}
+	router.GET("/slow", handler)
+	router.GET("/export", handler)
+
+	start := time.Now()
+	_, err := client.get(context.Background(), "/slow", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "default timeout should apply without a deadline")
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.get(ctx, "/slow", nil, nil)
+	assert.NoError(t, err, "caller deadline should take precedence")
+
+	_, err = client.get(context.Background(), "/export", nil, nil)
+	assert.NoError(t, err, "endpoint timeout should take precedence")
+
+	_, err = New(testApiKey, testApiSecret, WithTimeout(0))
+	assert.Error(t, err, "non positive timeout should be rejected")
+}
+
+func TestRateLimitOption(t *testing.T) {
+	setup(WithRateLimit(20, 1))
+	defer teardown()
+	router.GET("/limited", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	const requests = 6
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		_, err := client.get(context.Background(), "/limited", nil, nil)
+		assert.NoError(t, err)
+	}
+	// the first request uses the burst, the rest wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), (requests-1)*50*time.Millisecond-10*time.Millisecond)
+
+	_, err := New(testApiKey, testApiSecret, WithRateLimit(0, 1))
+	assert.Error(t, err, "non positive rate should be rejected")
+	_, err = New(testApiKey, testApiSecret, WithRateLimit(1, 0))
+	assert.Error(t, err, "zero burst should be rejected")
+}
+
+func TestRateLimitOptionContext(t *testing.T) {
+	setup(WithRateLimit(0.1, 1))
+	defer teardown()
+	router.GET("/limited", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/limited", nil, nil)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.get(ctx, "/limited", nil, nil)
+	assert.Error(t, err, "limiter should fail when the wait exceeds the deadline")
+	assert.WithinDuration(t, start, time.Now(), time.Second, "limiter should not block past the deadline")
+}
+
+func TestUserAgentOption(t *testing.T) {
+	setup(WithUserAgent("myapp/1.0"))
+	defer teardown()
+	router.GET("/ua", func(c *gin.Context) {
+		assert.Equal(t, "myapp/1.0 "+userAgent, c.Request.Header.Get("User-Agent"))
+		c.JSON(http.StatusOK, "ok")
+	})
+	_, err := client.get(context.Background(), "/ua", nil, nil)
+	assert.NoError(t, err)
+
+	_, err = New(testApiKey, testApiSecret, WithUserAgent(" "))
+	assert.Error(t, err, "empty user agent should be rejected")
+}
+
+func TestHeaderOption(t *testing.T) {
+	setup(
+		WithHeader("X-Request-Source", "backoffice"),
+		WithHeaders(http.Header{"X-Team": {"platform"}, "X-Override": {"default"}}),
+	)
+	defer teardown()
+	router.POST("/headers", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "backoffice", c.Request.Header.Get("X-Request-Source"))
+		assert.Equal(t, "platform", c.Request.Header.Get("X-Team"))
+		assert.Equal(t, "request", c.Request.Header.Get("X-Override"), "per-request header should take precedence")
+		assert.Equal(t, "application/json", c.Request.Header.Get("Content-Type"))
+		assert.Equal(t, userAgent, c.Request.Header.Get("User-Agent"))
+		c.JSON(http.StatusOK, "ok")
+	})
+	_, err := client.post(context.Background(), "/headers", nil, nil, http.Header{"X-Override": {"request"}})
+	assert.NoError(t, err)
+
+	for _, key := range []string{"authorization", "Content-Type", "User-Agent"} {
+		_, err = New(testApiKey, testApiSecret, WithHeader(key, "value"))
+		assert.Error(t, err, "header %s should be rejected", key)
+	}
+}
+
+func TestLoggerOption(t *testing.T) {
+	var buf bytes.Buffer
+	setup(Debug(true), WithLogger(log.New(&buf, "", 0)))
+	defer teardown()
+	router.GET("/logged", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	_, err := client.get(context.Background(), "/logged", nil, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "GET /logged HTTP/1.1", "request dump should be logged")
+	assert.Contains(t, buf.String(), "HTTP/1.1 200 OK", "response dump should be logged")
+	assert.NotContains(t, buf.String(), testApiKey, "api key should be redacted")
+
+	_, err = New(testApiKey, testApiSecret, WithLogger(nil))
+	assert.Error(t, err, "nil logger should be rejected")
+}
+
+func TestDebugWriterOption(t *testing.T) {
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		assert.NoError(t, err)
+		return
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var buf bytes.Buffer
+	setup(Debug(true), WithDebugWriter(&buf))
+	router.GET("/dumped", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	_, err = client.get(context.Background(), "/dumped", nil, nil)
+	teardown()
+	w.Close()
+	os.Stdout = stdout
+	written, _ := io.ReadAll(r)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "GET /dumped HTTP/1.1", "request dump should be written")
+	assert.Contains(t, buf.String(), "HTTP/1.1 200 OK", "response dump should be written")
+	assert.NotContains(t, buf.String(), testApiKey, "api key should be redacted")
+	assert.Empty(t, std.String(), "nothing should be logged to the standard logger")
+	assert.Empty(t, written, "nothing should be written to stdout")
+
+	_, err = New(testApiKey, testApiSecret, WithDebugWriter(nil))
+	assert.Error(t, err, "nil debug writer should be rejected")
+}
+
+func TestDebugBodyLimitOption(t *testing.T) {
+	var buf bytes.Buffer
+	setup(Debug(true), WithDebugWriter(&buf), WithDebugBodyLimit(100))
+	defer teardown()
+	router.POST("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, strings.Repeat("b", 500))
+	})
+
+	body := []byte(`"` + strings.Repeat("a", 1000) + `"`)
+	_, err := client.post(context.Background(), "/large", nil, body, nil)
+	assert.NoError(t, err)
+	dump := buf.String()
+	assert.Contains(t, dump, "POST /large HTTP/1.1", "request headers should be kept")
+	assert.Contains(t, dump, `"`+strings.Repeat("a", 99)+"...[truncated 902 bytes]", "request body should be truncated")
+	assert.Contains(t, dump, "HTTP/1.1 200 OK", "response headers should be kept")
+	assert.Contains(t, dump, `"`+strings.Repeat("b", 99)+"...[truncated 402 bytes]", "response body should be truncated")
+	assert.NotContains(t, dump, strings.Repeat("a", 100))
+
+	client, err := New(testApiKey, testApiSecret)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 64<<10, client.debugBodyLimit, "default limit should be 64 KiB")
+	}
+	_, err = New(testApiKey, testApiSecret, WithDebugBodyLimit(-1))
+	assert.Error(t, err, "negative limit should be rejected")
+}
+
+func TestTruncateDump(t *testing.T) {
+	dump := []byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n0123456789")
+	assert.Equal(t, dump, truncateDump(dump, 10), "body within the limit should be kept")
+	assert.Equal(t, "GET / HTTP/1.1\r\nHost: x\r\n\r\n0123...[truncated 6 bytes]", string(truncateDump(dump, 4)))
+	assert.Equal(t, "no separator", string(truncateDump([]byte("no separator"), 1)))
+}
+
+func TestDebugDefaultLogger(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret, Debug(true))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	assert.Equal(t, log.Default(), client.logger, "debug output should use the standard logger by default")
+}
+
+func TestPreferOption(t *testing.T) {
+	client, err := New(testApiKey, testApiSecret, WithPrefer(PreferRepresentation))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	assert.Equal(t, PreferRepresentation, client.prefer)
+	_, err = New(testApiKey, testApiSecret, WithPrefer(""))
+	assert.Error(t, err, "empty prefer value should be rejected")
+}
+
+func TestHookOptions(t *testing.T) {
+	var requests []*http.Request
+	var responses []*http.Response
+	var latencies []time.Duration
+	setup(
+		WithRequestHook(func(req *http.Request) {
+			requests = append(requests, req)
+		}),
+		WithResponseHook(func(resp *http.Response, latency time.Duration) {
+			responses = append(responses, resp)
+			latencies = append(latencies, latency)
+		}),
+	)
+	defer teardown()
+	router.GET("/hooked", func(c *gin.Context) {
+		time.Sleep(10 * time.Millisecond)
+		c.JSON(http.StatusAccepted, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/hooked", nil, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) && assert.Len(t, responses, 1) {
+		assert.NotEmpty(t, requests[0].Header.Get("Authorization"), "request hook should see the signed request")
+		assert.Equal(t, http.StatusAccepted, responses[0].StatusCode)
+		assert.GreaterOrEqual(t, latencies[0], 10*time.Millisecond)
+	}
+
+	// hooks also fire when the request fails
+	requests, responses = nil, nil
+	client.baseUrl = "http://127.0.0.1:1"
+	_, err = client.get(context.Background(), "/hooked", nil, nil)
+	assert.Error(t, err)
+	assert.Len(t, requests, 1)
+	if assert.Len(t, responses, 1) {
+		assert.Nil(t, responses[0], "failed request should report a nil response")
+	}
+
+	_, err = New(testApiKey, testApiSecret, WithRequestHook(nil))
+	assert.Error(t, err, "nil request hook should be rejected")
+	_, err = New(testApiKey, testApiSecret, WithResponseHook(nil))
+	assert.Error(t, err, "nil response hook should be rejected")
+}
+
+func TestBodyTransformerOption(t *testing.T) {
+	setup(WithBodyTransformer(func(body []byte) ([]byte, error) {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+		fields["correlation_id"] = "corr_123"
+		return json.Marshal(fields)
+	}))
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		// the signature is verified over the transformed body
+		testSignature(c, t)
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		assert.Equal(t, "corr_123", body["correlation_id"])
+		assert.Equal(t, "Test", body["name"])
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	_, err := client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Test"})
+	assert.NoError(t, err)
+
+	_, err = client.post(context.Background(), "/tenants", nil, []byte("not json"), nil)
+	assert.Error(t, err, "transformer errors should be returned")
+}
+
+func TestGlobalBufferLimitOption(t *testing.T) {
+	const bodySize = 600 << 10
+	setup(WithGlobalBufferLimit(1 << 20))
+	defer teardown()
+	var inFlight, maxInFlight int32
+	router.POST("/upload", func(c *gin.Context) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.post(context.Background(), "/upload", nil, bytes.Repeat([]byte("a"), bodySize), nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxInFlight, "bodies exceeding the limit together should be serialized")
+
+	// a body larger than the whole limit still goes through on its own
+	_, err := client.post(context.Background(), "/upload", nil, bytes.Repeat([]byte("a"), 2<<20), nil)
+	assert.NoError(t, err)
+
+	// waiting for capacity honors the context
+	assert.NoError(t, client.bufferSem.Acquire(context.Background(), 1<<20))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.post(ctx, "/upload", nil, bytes.Repeat([]byte("a"), bodySize), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	client.bufferSem.Release(1 << 20)
+
+	_, err = New(testApiKey, testApiSecret, WithGlobalBufferLimit(0))
+	assert.Error(t, err, "non-positive limit should be rejected")
+}
+
+func TestRequestCompressionOption(t *testing.T) {
+	setup(WithRequestCompression())
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		// the signature covers the bytes on the wire, i.e. the compressed body
+		testSignature(c, t)
+		body := io.Reader(c.Request.Body)
+		if c.Request.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(c.Request.Body)
+			if err != nil {
+				assert.NoError(t, err, "invalid gzip body")
+				return
+			}
+			body = zr
+		}
+		var request TenantCreateRequest
+		if err := json.NewDecoder(body).Decode(&request); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{
+			Name:        request.Name,
+			Description: c.Request.Header.Get("Content-Encoding"),
+		}))
+	})
+
+	description := strings.Repeat("large tenant ", 200)
+	tenant, err := client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Large", Description: description})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Large", tenant.Name)
+		assert.Equal(t, "gzip", tenant.Description, "large body should be compressed")
+	}
+
+	tenant, err = client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Small"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Small", tenant.Name)
+		assert.Empty(t, tenant.Description, "small body should be sent uncompressed")
+	}
+}
+
+func TestBodyTransformerCompression(t *testing.T) {
+	padding := strings.Repeat("p", 2<<10)
+	setup(WithRequestCompression(), WithBodyTransformer(func(body []byte) ([]byte, error) {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, err
+		}
+		fields["padding"] = padding
+		return json.Marshal(fields)
+	}))
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		if !assert.Equal(t, "gzip", c.Request.Header.Get("Content-Encoding"), "transformed body should be compressed") {
+			return
+		}
+		zr, err := gzip.NewReader(c.Request.Body)
+		if !assert.NoError(t, err, "invalid gzip body") {
+			return
+		}
+		var body map[string]interface{}
+		if !assert.NoError(t, json.NewDecoder(zr).Decode(&body)) {
+			return
+		}
+		assert.Equal(t, "Small", body["name"])
+		assert.Equal(t, padding, body["padding"])
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Small"}))
+	})
+
+	// the body is below the compression threshold until transformed, and the
+	// transformer fails on anything but the plain JSON body
+	_, err := client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Small"})
+	assert.NoError(t, err)
+}
+
+func TestMaxResponseSizeOption(t *testing.T) {
+	setup(WithMaxResponseSize(100))
+	defer teardown()
+	router.GET("/small", func(c *gin.Context) {
+		c.String(http.StatusOK, `"`+strings.Repeat("a", 98)+`"`)
+	})
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, strings.Repeat("a", 1000))
+	})
+	router.GET("/large-error", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, newErrorResponse(http.StatusInternalServerError, errors.New(strings.Repeat("a", 1000))))
+	})
+
+	res, err := client.get(context.Background(), "/small", nil, nil)
+	if assert.NoError(t, err, "body of exactly the limit should be read") {
+		assert.Len(t, res.Body, 100)
+	}
+	_, err = client.get(context.Background(), "/large", nil, nil)
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	_, err = client.get(context.Background(), "/large-error", nil, nil)
+	assert.ErrorIs(t, err, ErrResponseTooLarge, "error responses should be limited as well")
+
+	_, err = New(testApiKey, testApiSecret, WithMaxResponseSize(0))
+	assert.Error(t, err, "non-positive limit should be rejected")
+}
+
+func TestLimitedReadCloser(t *testing.T) {
+	body := io.NopCloser(strings.NewReader(strings.Repeat("a", 20)))
+	l := &limitedReadCloser{Reader: io.LimitReader(body, 11), body: body, limit: 10}
+	p := make([]byte, 4)
+	read := 0
+	var err error
+	for err == nil {
+		var n int
+		n, err = l.Read(p)
+		read += n
+	}
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Equal(t, 10, read, "bytes up to the limit should be returned")
+	for i := 0; i < 2; i++ {
+		n, err := l.Read(p)
+		assert.Equal(t, 0, n, "reads after the limit should return no bytes")
+		assert.ErrorIs(t, err, ErrResponseTooLarge)
+	}
+}
+
+func TestMaxRequestSizeOption(t *testing.T) {
+	var buf bytes.Buffer
+	nonces := &countingNonceGenerator{}
+	setup(WithMaxRequestSize(100), WithNonceGenerator(nonces), WithLogger(log.New(&buf, "", 0)))
+	defer teardown()
+	called := false
+	router.POST("/tenants", func(c *gin.Context) {
+		called = true
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	_, err := client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Test"})
+	assert.NoError(t, err, "small body should be sent")
+	called = false
+	nonces.calls = 0
+	_, err = client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: strings.Repeat("a", 200)})
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+	assert.False(t, called, "oversized body should not be sent")
+	assert.Zero(t, nonces.calls, "oversized body should not be signed")
+	assert.Contains(t, buf.String(), "rejected POST /tenants: body of 228 bytes exceeds the limit of 100 bytes")
+
+	_, err = New(testApiKey, testApiSecret, WithMaxRequestSize(-1))
+	assert.Error(t, err, "non-positive limit should be rejected")
 }
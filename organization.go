@@ -0,0 +1,120 @@
+package zerogate
+
+import (
+	"context"
+)
+
+// Organization ZeroGate organization
+type Organization struct {
+	Base
+	AuditBase
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OrganizationService organization service
+type OrganizationService service
+
+// OrganizationCreateRequest organization create request
+type OrganizationCreateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OrganizationUpdateRequest organization update request
+type OrganizationUpdateRequest struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OrganizationListParams organization list parameters
+type OrganizationListParams struct {
+	// Page is the 1-based page number, the server default is used when zero.
+	Page int
+	// PageSize is the number of organizations per page, the server default is used when zero.
+	PageSize int
+	// SortBy is the field to sort by, the server default is used when empty.
+	SortBy string
+	// Order is the sort order, OrderAsc or OrderDesc.
+	Order string
+}
+
+func (p *OrganizationListParams) query() map[string][]string {
+	if p == nil {
+		return listQuery(0, 0, "", "")
+	}
+	return listQuery(p.Page, p.PageSize, p.SortBy, p.Order)
+}
+
+func (p *OrganizationListParams) validate() error {
+	if p == nil {
+		return nil
+	}
+	return validateOrder(p.Order)
+}
+
+// Create creates a new organization, the returned organization is nil when
+// the server honors PreferMinimal with an empty response
+func (o *OrganizationService) Create(ctx context.Context, request *OrganizationCreateRequest, opts ...RequestOption) (*Organization, error) {
+	res, err := o.client.post(ctx, "/organizations", nil, request, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Organization](o.client, res, "organization")
+}
+
+// Get get the organization
+func (o *OrganizationService) Get(ctx context.Context, organizationId string, opts ...RequestOption) (*Organization, error) {
+	res, err := o.client.get(ctx, "/organizations/"+organizationId, nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Organization](o.client, res, "organization")
+}
+
+// List get organizations, params may be nil to use the server defaults
+func (o *OrganizationService) List(ctx context.Context, params *OrganizationListParams, opts ...RequestOption) (*List[*Organization], error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+	res, err := o.client.get(ctx, "/organizations", params.query(), nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeList[*Organization](o.client, res, "organization")
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		list.PageInfo = PageInfo{Page: params.Page, PageSize: params.PageSize}
+	}
+	return list, nil
+}
+
+// ListPage get a page of organizations like List, along with the requested
+// paging
+func (o *OrganizationService) ListPage(ctx context.Context, params *OrganizationListParams, opts ...RequestOption) (*Page[*Organization], error) {
+	list, err := o.List(ctx, params, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(list), nil
+}
+
+// Update updates the organization, the returned organization is nil when the
+// server honors PreferMinimal with an empty response
+func (o *OrganizationService) Update(ctx context.Context, organizationId string, request *OrganizationUpdateRequest, opts ...RequestOption) (*Organization, error) {
+	res, err := o.client.put(ctx, "/organizations/"+organizationId, nil, request, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Organization](o.client, res, "organization")
+}
+
+// Delete deletes the organization
+func (o *OrganizationService) Delete(ctx context.Context, organizationId string, opts ...RequestOption) error {
+	_, err := o.client.delete(ctx, "/organizations/"+organizationId, nil, nil, opts...)
+	return err
+}
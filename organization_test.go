@@ -0,0 +1,144 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationService_Create(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/organizations", func(c *gin.Context) {
+		assert.Equal(t, "application/json", c.Request.Header.Get("Content-Type"))
+		testSignature(c, t)
+		var json OrganizationCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		res := &Organization{
+			Base:        Base{Id: "org_7af4b215d3a00a5dc1f5abf3c3f9686c"},
+			Name:        json.Name,
+			Description: json.Description,
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(res))
+	})
+	req := &OrganizationCreateRequest{
+		Name:        "Test",
+		Description: "test organization",
+	}
+	organization, err := client.Organization.Create(context.TODO(), req)
+	if err != nil {
+		assert.NoError(t, err, "organization creation error")
+		return
+	}
+	assert.Equal(t, req.Name, organization.Name, "organization name is not equal")
+	assert.Equal(t, req.Description, organization.Description, "organization description is not equal")
+	assert.NotEmpty(t, organization.Id, "organization id is empty")
+}
+
+func TestOrganizationService_Get(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/organizations/:organizationId", func(c *gin.Context) {
+		assert.Equal(t, "/organizations/org_7af4b215d3a00a5dc1f5abf3c3f9686c", c.Request.URL.Path)
+		testSignature(c, t)
+		c.JSON(http.StatusOK, newSuccessResponse(&Organization{
+			Base: Base{Id: c.Param("organizationId")},
+			Name: "Test",
+		}))
+	})
+	organization, err := client.Organization.Get(context.TODO(), "org_7af4b215d3a00a5dc1f5abf3c3f9686c")
+	if err != nil {
+		assert.NoError(t, err, "organization get error")
+		return
+	}
+	assert.Equal(t, "org_7af4b215d3a00a5dc1f5abf3c3f9686c", organization.Id)
+	assert.Equal(t, "Test", organization.Name)
+}
+
+func TestOrganizationService_List(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/organizations", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "2", c.Query("page"), "page query param is not equal")
+		assert.Equal(t, "10", c.Query("page_size"), "page_size query param is not equal")
+		res := []*Organization{{
+			Base:        Base{Id: "org_7af4b215d3a00a5dc1f5abf3c3f9686c"},
+			Name:        "Test",
+			Description: "test organization",
+		}}
+		c.JSON(http.StatusOK, newSuccessPagingResponse(res, 11))
+	})
+
+	organizations, err := client.Organization.List(context.TODO(), &OrganizationListParams{Page: 2, PageSize: 10})
+	if err != nil {
+		assert.NoError(t, err, "organization list error")
+		return
+	}
+	assert.Len(t, organizations.Items, 1, "organizations length should be 1")
+	assert.Equal(t, int64(11), organizations.Total, "organizations total should be 11")
+	assert.Equal(t, PageInfo{Page: 2, PageSize: 10}, organizations.PageInfo, "page info is not equal")
+	assert.Equal(t, "Test", organizations.Items[0].Name, "organization name is not equal")
+}
+
+func TestOrganizationService_Update(t *testing.T) {
+	setup()
+	defer teardown()
+	router.PUT("/organizations/:organizationId", func(c *gin.Context) {
+		assert.Equal(t, "application/json", c.Request.Header.Get("Content-Type"))
+		testSignature(c, t)
+		var json OrganizationUpdateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		res := &Organization{
+			Base:        Base{Id: c.Param("organizationId")},
+			Name:        json.Name,
+			Description: json.Description,
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(res))
+	})
+	req := &OrganizationUpdateRequest{
+		Id:          "org_7af4b215d3a00a5dc1f5abf3c3f9686c",
+		Name:        "Test Update",
+		Description: "test organization update",
+	}
+	organization, err := client.Organization.Update(context.TODO(), req.Id, req)
+	if err != nil {
+		assert.NoError(t, err, "organization update error")
+		return
+	}
+	assert.Equal(t, req.Name, organization.Name, "organization name is not equal")
+	assert.Equal(t, req.Description, organization.Description, "organization description is not equal")
+	assert.Equal(t, req.Id, organization.Id, "organization id is not equal")
+}
+
+func TestOrganizationService_Delete(t *testing.T) {
+	setup()
+	defer teardown()
+	router.DELETE("/organizations/:organizationId", func(c *gin.Context) {
+		testSignature(c, t)
+		if c.Param("organizationId") != "org_7af4b215d3a00a5dc1f5abf3c3f9686c" {
+			c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "organization not found"))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(true))
+	})
+
+	err := client.Organization.Delete(context.TODO(), "org_7af4b215d3a00a5dc1f5abf3c3f9686c")
+	assert.NoError(t, err, "organization delete error")
+
+	err = client.Organization.Delete(context.TODO(), "org_missing")
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr, "error should be an *Error") {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, "organization not found", apiErr.Response.ErrorMessage)
+	}
+}
@@ -0,0 +1,18 @@
+package zerogate
+
+import "context"
+
+// Permission ZeroGate permission
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ListPermissions get the catalog of available permissions
+func (c *Client) ListPermissions(ctx context.Context, opts ...RequestOption) ([]Permission, error) {
+	res, err := c.get(ctx, "/permissions", nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[[]Permission](c, res, "permission")
+}
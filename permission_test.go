@@ -0,0 +1,32 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ListPermissions(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/permissions", func(c *gin.Context) {
+		assert.Equal(t, http.MethodGet, c.Request.Method, "Expected method 'GET', got %s", c.Request.Method)
+		testSignature(c, t)
+		c.JSON(http.StatusOK, newSuccessResponse([]Permission{
+			{Name: "tenants:read", Description: "Read tenants"},
+			{Name: "tenants:write", Description: "Create and update tenants"},
+		}))
+	})
+
+	permissions, err := client.ListPermissions(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "permission list error")
+		return
+	}
+	assert.Len(t, permissions, 2, "permissions length should be 2")
+	assert.Equal(t, "tenants:read", permissions[0].Name, "permission name is not equal")
+	assert.Equal(t, "Create and update tenants", permissions[1].Description, "permission description is not equal")
+}
@@ -0,0 +1,29 @@
+package zerogate
+
+import (
+	"context"
+)
+
+// Prefer header values
+const (
+	// PreferMinimal asks the server to omit the resource from the response.
+	PreferMinimal = "return=minimal"
+	// PreferRepresentation asks the server to return the full resource.
+	PreferRepresentation = "return=representation"
+)
+
+type preferKey struct{}
+
+// WithCallPrefer returns a context overriding the Prefer header for the calls
+// made with it.
+func WithCallPrefer(ctx context.Context, value string) context.Context {
+	return context.WithValue(ctx, preferKey{}, value)
+}
+
+// preferHeader returns the Prefer header value for the call.
+func (c *Client) preferHeader(ctx context.Context) string {
+	if value, ok := ctx.Value(preferKey{}).(string); ok {
+		return value
+	}
+	return c.prefer
+}
@@ -0,0 +1,123 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestOption is a functional option customizing a single API call.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	headers        http.Header
+	query          map[string][]string
+	timeout        time.Duration
+	idempotencyKey string
+}
+
+// WithRequestHeader adds a header to the request.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		o.headers.Add(key, value)
+	}
+}
+
+// WithIfModifiedSince sends If-Modified-Since with the request, e.g. with the
+// Updated time of a tenant fetched before. When the resource is unchanged the
+// server answers 304 Not Modified, which service methods return as
+// ErrNotModified and Do as an APIResponse with NotModified set.
+func WithIfModifiedSince(t time.Time) RequestOption {
+	return WithRequestHeader("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+}
+
+// WithIfMatch sends If-Match with the request for optimistic concurrency, e.g.
+// with the ETag of a tenant fetched before, so that the server rejects the
+// update with 412 Precondition Failed when the tenant changed meanwhile. As
+// If-Match uses the strong comparison, which a weak ETag never passes, a weak
+// W/ indicator is removed.
+func WithIfMatch(etag string) RequestOption {
+	return WithRequestHeader("If-Match", strongETag(etag))
+}
+
+// WithRequestQuery sets a query parameter of the request, replacing the value
+// set by the method.
+func WithRequestQuery(key string, values ...string) RequestOption {
+	return func(o *requestOptions) {
+		if o.query == nil {
+			o.query = make(map[string][]string)
+		}
+		o.query[key] = values
+	}
+}
+
+// WithRequestTimeout bounds the request, including its retries, replacing
+// the client and endpoint timeouts.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRequestIdempotencyKey sends the Idempotency-Key with a POST request,
+// like WithCallIdempotencyKey.
+func WithRequestIdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// apply returns the context, query and headers of the request with the
+// options applied, leaving the supplied query and headers untouched.
+func (o *requestOptions) apply(ctx context.Context, query map[string][]string, headers http.Header) (context.Context, map[string][]string, http.Header) {
+	if o.idempotencyKey != "" {
+		ctx = WithCallIdempotencyKey(ctx, o.idempotencyKey)
+	}
+	if len(o.query) > 0 {
+		merged := make(map[string][]string, len(query)+len(o.query))
+		for k, v := range query {
+			merged[k] = v
+		}
+		for k, v := range o.query {
+			merged[k] = v
+		}
+		query = merged
+	}
+	if len(o.headers) > 0 {
+		headers = combineHeaders(headers, o.headers)
+	}
+	return ctx, query, headers
+}
+
+type contextHeadersKey struct{}
+
+// WithContextHeader returns a context adding the header to the calls made
+// with it, e.g. for a tenant ID or trace baggage set by HTTP middleware.
+// Headers set by the method or with WithRequestHeader take precedence.
+func WithContextHeader(ctx context.Context, key, value string) context.Context {
+	headers := contextHeaders(ctx).Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Add(key, value)
+	return context.WithValue(ctx, contextHeadersKey{}, headers)
+}
+
+// contextHeaders returns the headers added with WithContextHeader.
+func contextHeaders(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(contextHeadersKey{}).(http.Header)
+	return headers
+}
@@ -0,0 +1,147 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestOptions(t *testing.T) {
+	setup(WithHeader("X-Team", "platform"))
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "corr_123", c.GetHeader("X-Correlation-ID"), "per-request header should reach the server")
+		assert.Equal(t, "platform", c.GetHeader("X-Team"), "client headers should still be sent")
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Base: Base{Id: c.Param("tenantId")}, Name: "Test"}))
+	})
+	router.GET("/tenants", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newSuccessPagingResponse([]*Tenant{{Name: c.Query("page") + "/" + c.Query("page_size") + "/" + c.Query("filter")}}, 1))
+	})
+	router.POST("/tenants", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: c.GetHeader("Idempotency-Key")}))
+	})
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.Status(http.StatusNoContent)
+	})
+
+	tenant, err := client.Tenant.Get(context.Background(), "ten_1", WithRequestHeader("X-Correlation-ID", "corr_123"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ten_1", tenant.Id)
+	}
+
+	list, err := client.Tenant.List(context.Background(), &TenantListParams{Page: 2, PageSize: 10},
+		WithRequestQuery("page_size", "5"), WithRequestQuery("filter", "active"))
+	if assert.NoError(t, err) && assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "2/5/active", list.Items[0].Name, "query options should override and extend the method query")
+	}
+
+	tenant, err = client.Tenant.Create(context.Background(), &TenantCreateRequest{Name: "Test"}, WithRequestIdempotencyKey("idem_123"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "idem_123", tenant.Name)
+	}
+
+	err = client.Tenant.Delete(context.Background(), "ten_1", WithRequestTimeout(50*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "per-request timeout should apply")
+
+	// calls without options keep working
+	_, err = client.Tenant.Get(context.Background(), "ten_1", WithRequestHeader("X-Correlation-ID", "corr_123"), nil)
+	assert.NoError(t, err)
+}
+
+func TestWithContextHeader(t *testing.T) {
+	setup(WithHeader("X-Team", "platform"), WithHeader("X-Region", "eu"))
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "ten_1", c.GetHeader("X-Tenant-ID"), "context header should reach the server")
+		assert.Equal(t, []string{"a=1", "b=2"}, c.Request.Header.Values("Baggage"), "context headers should accumulate")
+		assert.Equal(t, "billing", c.GetHeader("X-Team"), "context header should override client headers")
+		assert.Equal(t, "us", c.GetHeader("X-Region"), "request header should override context headers")
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Base: Base{Id: c.Param("tenantId")}}))
+	})
+
+	ctx := WithContextHeader(context.Background(), "X-Tenant-ID", "ten_1")
+	ctx = WithContextHeader(ctx, "Baggage", "a=1")
+	ctx = WithContextHeader(ctx, "Baggage", "b=2")
+	ctx = WithContextHeader(ctx, "X-Team", "billing")
+	parent := WithContextHeader(ctx, "X-Region", "ap")
+	_, err := client.Tenant.Get(parent, "ten_1", WithRequestHeader("X-Region", "us"))
+	assert.NoError(t, err)
+	assert.Empty(t, contextHeaders(ctx).Get("X-Region"), "derived contexts should not change their parent")
+}
+
+func TestWithIfModifiedSince(t *testing.T) {
+	setup()
+	defer teardown()
+	updated := NewTimestamp(1700000000)
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !updated.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		tenant := &Tenant{Base: Base{Id: c.Param("tenantId")}, Name: "Test"}
+		tenant.Updated = updated
+		c.JSON(http.StatusOK, newSuccessResponse(tenant))
+	})
+	router.GET("/tenants", func(c *gin.Context) {
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !updated.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessPagingResponse([]*Tenant{{Name: "Test"}}, 1))
+	})
+
+	tenant, err := client.Tenant.Get(context.Background(), "ten_1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, updated.Unix(), tenant.Updated.Unix())
+
+	_, err = client.Tenant.Get(context.Background(), "ten_1", WithIfModifiedSince(tenant.Updated.Time))
+	assert.ErrorIs(t, err, ErrNotModified, "unchanged tenant should be reported as not modified")
+
+	res, err := client.Do(context.Background(), http.MethodGet, "/tenants/ten_1", nil, nil, WithIfModifiedSince(tenant.Updated.Time))
+	if assert.NoError(t, err, "304 should not be an error response") {
+		assert.True(t, res.NotModified)
+		assert.Empty(t, res.Body)
+	}
+
+	_, _, err = client.Tenant.ListLenient(context.Background(), nil, WithIfModifiedSince(tenant.Updated.Time))
+	assert.ErrorIs(t, err, ErrNotModified, "unchanged list should be reported as not modified")
+
+	tenant, err = client.Tenant.Get(context.Background(), "ten_1", WithIfModifiedSince(updated.Add(-time.Hour)))
+	if assert.NoError(t, err, "modified tenant should be returned") {
+		assert.Equal(t, "ten_1", tenant.Id)
+	}
+}
+
+func TestWithIfMatch(t *testing.T) {
+	setup()
+	defer teardown()
+	router.PUT("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		if c.GetHeader("If-Match") != `"v2"` {
+			c.JSON(http.StatusPreconditionFailed, newErrorResponse(http.StatusPreconditionFailed, errors.New("tenant changed")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	for _, etag := range []string{`"v2"`, `W/"v2"`} {
+		_, err := client.Tenant.Update(context.Background(), "ten_1", &TenantUpdateRequest{Name: "Test"}, WithIfMatch(etag))
+		assert.NoError(t, err, "ETag %s should match", etag)
+	}
+	_, err := client.Tenant.Update(context.Background(), "ten_1", &TenantUpdateRequest{Name: "Test"}, WithIfMatch(`"v1"`))
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusPreconditionFailed, apiErr.StatusCode)
+	}
+}
@@ -0,0 +1,32 @@
+package zerogate
+
+import (
+	"context"
+	"fmt"
+)
+
+// requestIDHeader correlates a request with the server logs.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithCallRequestID returns a context sending the given X-Request-ID for the
+// calls made with it, instead of a generated one.
+func WithCallRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by the context, empty when none
+// was set.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	b := randomBytes(16)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
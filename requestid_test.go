@@ -0,0 +1,47 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const uuidPattern = `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`
+
+func TestRequestID(t *testing.T) {
+	setup(WithRetry(1, time.Millisecond))
+	defer teardown()
+	var ids []string
+	router.GET("/tenants", func(c *gin.Context) {
+		ids = append(ids, c.GetHeader("X-Request-ID"))
+		c.JSON(http.StatusOK, newSuccessPagingResponse([]*Tenant{}, 0))
+	})
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		ids = append(ids, c.GetHeader("X-Request-ID"))
+		c.Header("X-Request-ID", "srv_"+c.GetHeader("X-Request-ID"))
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(http.StatusServiceUnavailable, errors.New("unavailable")))
+	})
+
+	res, err := client.get(context.Background(), "/tenants", nil, nil)
+	if assert.NoError(t, err) && assert.Len(t, ids, 1) {
+		assert.Regexp(t, uuidPattern, ids[0], "request ID should be a UUID")
+		assert.Equal(t, ids[0], res.RequestID, "sent request ID should be recorded when not echoed")
+	}
+
+	ids = nil
+	_, err = client.get(WithCallRequestID(context.Background(), "req_custom"), "/tenants/ten_1", nil, nil)
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, "srv_req_custom", apiErr.RequestID, "error should carry the echoed request ID")
+	}
+	assert.Equal(t, []string{"req_custom", "req_custom"}, ids, "retries should share the request ID")
+
+	assert.Equal(t, "req_custom", RequestID(WithCallRequestID(context.Background(), "req_custom")))
+	assert.Empty(t, RequestID(context.Background()))
+	assert.NotEqual(t, newRequestID(), newRequestID())
+}
@@ -0,0 +1,216 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retry behavior of failed requests
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries, zero disables retries.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled after each
+	// attempt. It is ignored when Backoff is set.
+	BaseDelay time.Duration
+	// Backoff computes the delay before each retry, nil for exponential
+	// backoff from BaseDelay.
+	Backoff Backoff
+}
+
+// delay returns the delay before the given retry attempt, starting at zero.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff.Next(attempt)
+	}
+	return backoff(p.BaseDelay, attempt)
+}
+
+// Backoff strategy computing the delay before a retry. Attempts start at zero
+// for the first retry.
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// ConstantBackoff waits the same delay before every retry.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// Next returns the constant delay.
+func (b ConstantBackoff) Next(int) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff doubles the delay after each retry, starting at Base and
+// capped at Max when Max is set.
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next returns Base doubled attempt times, capped at Max.
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	d := backoff(b.Base, attempt)
+	// an overflowing shift turns negative or zero
+	if b.Max > 0 && (d > b.Max || d <= 0 && b.Base > 0) {
+		return b.Max
+	}
+	return d
+}
+
+// JitteredBackoff waits a random delay between zero and the exponential
+// backoff delay ("full jitter"), spreading out retries of concurrent clients.
+type JitteredBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next returns a random delay in [0, ExponentialBackoff delay].
+func (b JitteredBackoff) Next(attempt int) time.Duration {
+	d := ExponentialBackoff{Base: b.Base, Max: b.Max}.Next(attempt)
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+type retryPolicyKey struct{}
+
+// WithCallRetry returns a context overriding the client retry policy for the
+// calls made with it, e.g. RetryPolicy{} disables retries for a single call.
+func WithCallRetry(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// idempotencyKeyHeader lets the server deduplicate retried POST requests.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// WithCallIdempotencyKey returns a context sending the given Idempotency-Key
+// with the POST requests made with it, which makes them retryable. A key is
+// generated automatically for POST requests when retries are enabled.
+func WithCallIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// ensureIdempotencyKey returns the headers with the Idempotency-Key of a POST
+// request set, along with whether the request carries a key. The key comes
+// from the headers, the context, or is generated when retries are enabled,
+// and is then reused by every attempt.
+func ensureIdempotencyKey(ctx context.Context, method string, headers http.Header, retry RetryPolicy) (http.Header, bool) {
+	if method != http.MethodPost {
+		return headers, false
+	}
+	if headers.Get(idempotencyKeyHeader) != "" {
+		return headers, true
+	}
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	if key == "" && retry.MaxRetries > 0 {
+		key = newRequestID()
+	}
+	if key == "" {
+		return headers, false
+	}
+	headers = headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(idempotencyKeyHeader, key)
+	return headers, true
+}
+
+// isRetryable reports whether a failed attempt may be retried. Only
+// idempotent methods, and POST requests carrying an Idempotency-Key, failing
+// with a rate limit, a server error or a transient network error are retried.
+func isRetryable(method string, idempotent bool, err error) bool {
+	if err == nil || !replayable(method, idempotent) {
+		return false
+	}
+	return IsRateLimited(err) || IsServerError(err) || isTransientNetworkError(err)
+}
+
+// replayable reports whether a request may be sent again without risking a
+// duplicate side effect: idempotent methods and POST requests carrying an
+// Idempotency-Key.
+func replayable(method string, idempotent bool) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return idempotent
+	}
+	return false
+}
+
+// isTransientNetworkError reports whether the request failed with a timeout,
+// a refused or reset connection or a temporary DNS failure. Context errors
+// are never transient, as retrying cannot succeed.
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryWanted reports whether the user supplied retry predicate asks for the
+// attempt to be retried. It is not consulted for requests that cannot be
+// replayed safely.
+func (c *Client) retryWanted(method string, idempotent bool, res *APIResponse, err error) bool {
+	return c.retryPredicate != nil && replayable(method, idempotent) && c.retryPredicate(res, err)
+}
+
+// backoff returns the exponential delay before the given retry attempt.
+func backoff(base time.Duration, attempt int) time.Duration {
+	return base << attempt
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter parses a Retry-After header value given either in seconds
+// or as an HTTP date. It returns zero for missing or invalid values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	if d := date.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,397 @@
+package zerogate
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Retry(t *testing.T) {
+	setup(WithRetry(3, 10*time.Millisecond))
+	defer teardown()
+	requests := 0
+	router.GET("/flaky", func(c *gin.Context) {
+		requests++
+		testSignature(c, t)
+		if requests <= 2 {
+			c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+			return
+		}
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	res, err := client.get(context.Background(), "/flaky", nil, nil)
+	if err != nil {
+		assert.NoError(t, err, "request should succeed after retries")
+		return
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, requests, "request should be retried twice")
+}
+
+func TestClient_RetryExhausted(t *testing.T) {
+	setup(WithRetry(2, time.Millisecond))
+	defer teardown()
+	requests := 0
+	router.GET("/down", func(c *gin.Context) {
+		requests++
+		c.JSON(http.StatusInternalServerError, newErrorsResponse(http.StatusInternalServerError, "internal error"))
+	})
+
+	_, err := client.get(context.Background(), "/down", nil, nil)
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	}
+	assert.Equal(t, 3, requests, "request should be attempted max retries + 1 times")
+}
+
+func TestClient_RetryClientError(t *testing.T) {
+	setup(WithRetry(3, time.Millisecond))
+	defer teardown()
+	requests := 0
+	router.GET("/missing", func(c *gin.Context) {
+		requests++
+		c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "not found"))
+	})
+
+	_, err := client.get(context.Background(), "/missing", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests, "client errors should not be retried")
+}
+
+func TestClient_RetryIdempotencyKey(t *testing.T) {
+	setup(WithRetry(3, time.Millisecond))
+	defer teardown()
+	var keys []string
+	router.POST("/create", func(c *gin.Context) {
+		keys = append(keys, c.GetHeader("Idempotency-Key"))
+		c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+	})
+
+	// a key is generated when retries are enabled and reused by every attempt
+	_, err := client.post(context.Background(), "/create", nil, nil, nil)
+	assert.Error(t, err)
+	if assert.Len(t, keys, 4, "POST with an idempotency key should be retried") {
+		assert.NotEmpty(t, keys[0])
+		for _, key := range keys {
+			assert.Equal(t, keys[0], key, "retries should reuse the idempotency key")
+		}
+	}
+
+	keys = nil
+	_, err = client.post(WithCallIdempotencyKey(context.Background(), "idem_123"), "/create", nil, nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"idem_123", "idem_123", "idem_123", "idem_123"}, keys, "supplied key should be used")
+
+	// without retries POST requests carry no key and are sent once
+	keys = nil
+	_, err = client.post(WithCallRetry(context.Background(), RetryPolicy{}), "/create", nil, nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, []string{""}, keys)
+}
+
+func TestClient_RetryContextCanceled(t *testing.T) {
+	setup(WithRetry(5, time.Second))
+	defer teardown()
+	router.GET("/down", func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.get(ctx, "/down", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.WithinDuration(t, start, time.Now(), 500*time.Millisecond, "backoff should stop when the context is done")
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.False(t, isRetryable(http.MethodGet, false, nil))
+	assert.False(t, isRetryable(http.MethodGet, false, errors.New("network error")))
+	assert.True(t, isRetryable(http.MethodGet, false, &Error{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, isRetryable(http.MethodPut, false, &Error{StatusCode: http.StatusBadGateway}))
+	assert.True(t, isRetryable(http.MethodDelete, false, &Error{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, isRetryable(http.MethodPost, false, &Error{StatusCode: http.StatusInternalServerError}))
+	assert.True(t, isRetryable(http.MethodPost, true, &Error{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, isRetryable(http.MethodPost, true, &Error{StatusCode: http.StatusConflict}))
+	assert.False(t, isRetryable(http.MethodGet, false, &Error{StatusCode: http.StatusBadRequest}))
+}
+
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	assert.Equal(t, 100*time.Millisecond, backoff(base, 0))
+	assert.Equal(t, 200*time.Millisecond, backoff(base, 1))
+	assert.Equal(t, 400*time.Millisecond, backoff(base, 2))
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	sequence := func(b Backoff, n int) []time.Duration {
+		var delays []time.Duration
+		for attempt := 0; attempt < n; attempt++ {
+			delays = append(delays, b.Next(attempt))
+		}
+		return delays
+	}
+	ms := time.Millisecond
+
+	assert.Equal(t, []time.Duration{50 * ms, 50 * ms, 50 * ms}, sequence(ConstantBackoff{Delay: 50 * ms}, 3))
+	assert.Equal(t, []time.Duration{10 * ms, 20 * ms, 40 * ms, 80 * ms}, sequence(ExponentialBackoff{Base: 10 * ms}, 4))
+	assert.Equal(t, []time.Duration{10 * ms, 20 * ms, 25 * ms, 25 * ms}, sequence(ExponentialBackoff{Base: 10 * ms, Max: 25 * ms}, 4))
+	assert.Equal(t, 25*ms, ExponentialBackoff{Base: 10 * ms, Max: 25 * ms}.Next(100), "overflowing delays should be capped")
+
+	jittered := JitteredBackoff{Base: 10 * ms, Max: 60 * ms}
+	for attempt := 0; attempt < 6; attempt++ {
+		upper := ExponentialBackoff{Base: 10 * ms, Max: 60 * ms}.Next(attempt)
+		seen := make(map[time.Duration]struct{})
+		for i := 0; i < 100; i++ {
+			d := jittered.Next(attempt)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, upper, "jitter should stay within the exponential delay")
+			seen[d] = struct{}{}
+		}
+		assert.Greater(t, len(seen), 1, "jittered delays should vary")
+	}
+	assert.Zero(t, JitteredBackoff{}.Next(0))
+}
+
+func TestBackoffOption(t *testing.T) {
+	var delays []time.Duration
+	recorder := backoffFunc(func(attempt int) time.Duration {
+		delays = append(delays, time.Duration(attempt))
+		return time.Millisecond
+	})
+	// the backoff is kept regardless of the option order
+	setup(WithBackoff(recorder), WithRetry(2, time.Hour))
+	defer teardown()
+	router.GET("/down", func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+	})
+
+	start := time.Now()
+	_, err := client.get(context.Background(), "/down", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, []time.Duration{0, 1}, delays, "backoff should be consulted for each retry")
+	assert.Less(t, time.Since(start), time.Second, "base delay should be ignored")
+
+	_, err = New(testApiKey, testApiSecret, WithBackoff(nil))
+	assert.Error(t, err, "nil backoff should be rejected")
+}
+
+type backoffFunc func(attempt int) time.Duration
+
+func (f backoffFunc) Next(attempt int) time.Duration {
+	return f(attempt)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now), "past dates should not delay")
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestClient_RetryAfterError(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/seconds", func(c *gin.Context) {
+		c.Header("Retry-After", "7")
+		c.JSON(http.StatusTooManyRequests, newErrorsResponse(http.StatusTooManyRequests, "rate limited"))
+	})
+	router.GET("/date", func(c *gin.Context) {
+		c.Header("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusTooManyRequests, newErrorsResponse(http.StatusTooManyRequests, "rate limited"))
+	})
+
+	var apiErr *Error
+	_, err := client.get(context.Background(), "/seconds", nil, nil)
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 7*time.Second, apiErr.RetryAfter)
+	}
+	_, err = client.get(context.Background(), "/date", nil, nil)
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.InDelta(t, time.Hour, apiErr.RetryAfter, float64(5*time.Second))
+	}
+	_, err = client.get(context.Background(), "/missing", nil, nil)
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, time.Duration(0), apiErr.RetryAfter)
+	}
+}
+
+func TestClient_RetryHonorsRetryAfter(t *testing.T) {
+	// the base delay is long enough that the test would time out if the
+	// Retry-After header were ignored
+	setup(WithRetry(1, time.Minute))
+	defer teardown()
+	requests := 0
+	router.GET("/limited", func(c *gin.Context) {
+		requests++
+		if requests == 1 {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusTooManyRequests, newErrorsResponse(http.StatusTooManyRequests, "rate limited"))
+			return
+		}
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := client.get(ctx, "/limited", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "retry should wait for Retry-After")
+}
+
+func TestClient_CallRetry(t *testing.T) {
+	setup(WithRetry(3, time.Millisecond))
+	defer teardown()
+	requests := 0
+	router.GET("/down", func(c *gin.Context) {
+		requests++
+		c.JSON(http.StatusServiceUnavailable, newErrorsResponse(http.StatusServiceUnavailable, "unavailable"))
+	})
+
+	ctx := WithCallRetry(context.Background(), RetryPolicy{})
+	_, err := client.get(ctx, "/down", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests, "retries should be disabled for the call")
+
+	requests = 0
+	ctx = WithCallRetry(context.Background(), RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond})
+	_, err = client.get(ctx, "/down", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests, "call policy should override the client max retries")
+
+	requests = 0
+	_, err = client.get(context.Background(), "/down", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 4, requests, "client policy should apply without an override")
+}
+
+func TestClient_RetryNetworkError(t *testing.T) {
+	failures := 2
+	var dials int32
+	dialer := &net.Dialer{}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if atomic.AddInt32(&dials, 1) <= int32(failures) {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: syscall.ECONNREFUSED}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+	setup(HTTPClient(&http.Client{Transport: transport}), WithRetry(3, time.Millisecond))
+	defer teardown()
+	router.GET("/flaky", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.PATCH("/flaky", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/flaky", nil, nil)
+	assert.NoError(t, err, "refused connections should be retried")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&dials))
+
+	// non idempotent requests fail immediately
+	transport.CloseIdleConnections()
+	atomic.StoreInt32(&dials, 0)
+	_, err = client.doRequest(context.Background(), http.MethodPatch, "/flaky", nil, nil, nil)
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+
+	// context cancellation propagates without retrying
+	transport.CloseIdleConnections()
+	atomic.StoreInt32(&dials, 0)
+	failures = 100
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.get(ctx, "/flaky", nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, atomic.LoadInt32(&dials), int32(1), "canceled requests should not be retried")
+}
+
+func TestIsTransientNetworkError(t *testing.T) {
+	assert.True(t, isTransientNetworkError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.True(t, isTransientNetworkError(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	assert.True(t, isTransientNetworkError(&net.DNSError{Err: "server misbehaving", IsTemporary: true}))
+	assert.False(t, isTransientNetworkError(&net.DNSError{Err: "no such host", IsNotFound: true}))
+	assert.False(t, isTransientNetworkError(context.Canceled))
+	assert.False(t, isTransientNetworkError(context.DeadlineExceeded))
+	assert.False(t, isTransientNetworkError(errors.New("boom")))
+	assert.True(t, isRetryable(http.MethodGet, false, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.False(t, isRetryable(http.MethodPost, false, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+}
+
+func TestRetryPredicateOption(t *testing.T) {
+	// a server specific error code marking a transient client error
+	const resourceBusy = 4001
+	var seen []error
+	setup(WithRetry(3, time.Millisecond), WithRetryPredicate(func(res *APIResponse, err error) bool {
+		seen = append(seen, err)
+		var apiErr *Error
+		return errors.As(err, &apiErr) && apiErr.Response.ErrorCode == resourceBusy
+	}))
+	defer teardown()
+	requests := 0
+	router.GET("/busy", func(c *gin.Context) {
+		requests++
+		if requests < 3 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(resourceBusy, errors.New("resource busy")))
+			return
+		}
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.GET("/invalid", func(c *gin.Context) {
+		requests++
+		c.JSON(http.StatusBadRequest, newErrorResponse(http.StatusBadRequest, errors.New("invalid")))
+	})
+
+	_, err := client.get(context.Background(), "/busy", nil, nil)
+	assert.NoError(t, err, "custom error code should be retried")
+	assert.Equal(t, 3, requests)
+	if assert.Len(t, seen, 3) {
+		assert.Nil(t, seen[2], "predicate should see the successful attempt")
+	}
+
+	requests = 0
+	_, err = client.get(context.Background(), "/invalid", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests, "other client errors should not be retried")
+
+	// nor for requests that cannot be replayed safely
+	requests, seen = 0, nil
+	router.PATCH("/busy", func(c *gin.Context) {
+		requests++
+		c.JSON(http.StatusBadRequest, newErrorResponse(resourceBusy, errors.New("resource busy")))
+	})
+	_, err = client.patch(context.Background(), "/busy", nil, []byte(`{}`), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests, "PATCH should not be retried by the predicate")
+	assert.Empty(t, seen)
+
+	// the predicate is not consulted without retries
+	requests, seen = 0, nil
+	_, err = client.get(WithCallRetry(context.Background(), RetryPolicy{}), "/busy", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Empty(t, seen)
+
+	_, err = New(testApiKey, testApiSecret, WithRetryPredicate(nil))
+	assert.Error(t, err, "nil predicate should be rejected")
+}
@@ -0,0 +1,128 @@
+package zerogate
+
+import (
+	"context"
+)
+
+// Role ZeroGate role of a tenant
+type Role struct {
+	TenantBase
+	AuditBase
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleService tenant scoped role service, see Client.ForTenant
+type RoleService tenantService
+
+// RoleCreateRequest role create request
+type RoleCreateRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleUpdateRequest role update request
+type RoleUpdateRequest struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleListParams role list parameters
+type RoleListParams struct {
+	// Page is the 1-based page number, the server default is used when zero.
+	Page int
+	// PageSize is the number of roles per page, the server default is used when zero.
+	PageSize int
+	// SortBy is the field to sort by, the server default is used when empty.
+	SortBy string
+	// Order is the sort order, OrderAsc or OrderDesc.
+	Order string
+}
+
+func (p *RoleListParams) query() map[string][]string {
+	if p == nil {
+		return listQuery(0, 0, "", "")
+	}
+	return listQuery(p.Page, p.PageSize, p.SortBy, p.Order)
+}
+
+func (p *RoleListParams) validate() error {
+	if p == nil {
+		return nil
+	}
+	return validateOrder(p.Order)
+}
+
+func (r *RoleService) path(resource string) string {
+	return (*tenantService)(r).path(resource)
+}
+
+// Create creates a new role, the returned role is nil when the server honors
+// PreferMinimal with an empty response
+func (r *RoleService) Create(ctx context.Context, request *RoleCreateRequest, opts ...RequestOption) (*Role, error) {
+	res, err := r.client.post(ctx, r.path("/roles"), nil, request, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Role](r.client, res, "role")
+}
+
+// Get get the role
+func (r *RoleService) Get(ctx context.Context, roleId string, opts ...RequestOption) (*Role, error) {
+	res, err := r.client.get(ctx, r.path("/roles/"+roleId), nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Role](r.client, res, "role")
+}
+
+// List get the roles of the tenant, params may be nil to use the server
+// defaults
+func (r *RoleService) List(ctx context.Context, params *RoleListParams, opts ...RequestOption) (*List[*Role], error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.client.get(ctx, r.path("/roles"), params.query(), nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeList[*Role](r.client, res, "role")
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		list.PageInfo = PageInfo{Page: params.Page, PageSize: params.PageSize}
+	}
+	return list, nil
+}
+
+// ListPage get a page of the roles of the tenant like List, along with the
+// requested paging
+func (r *RoleService) ListPage(ctx context.Context, params *RoleListParams, opts ...RequestOption) (*Page[*Role], error) {
+	list, err := r.List(ctx, params, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(list), nil
+}
+
+// Update updates the role, the returned role is nil when the server honors
+// PreferMinimal with an empty response
+func (r *RoleService) Update(ctx context.Context, roleId string, request *RoleUpdateRequest, opts ...RequestOption) (*Role, error) {
+	res, err := r.client.put(ctx, r.path("/roles/"+roleId), nil, request, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Role](r.client, res, "role")
+}
+
+// Delete deletes the role
+func (r *RoleService) Delete(ctx context.Context, roleId string, opts ...RequestOption) error {
+	_, err := r.client.delete(ctx, r.path("/roles/"+roleId), nil, nil, opts...)
+	return err
+}
@@ -0,0 +1,70 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleService_List(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants/:tenantId/roles", func(c *gin.Context) {
+		assert.Equal(t, "/tenants/ten_ea87af463d9fc38203690805c1c1fa33/roles", c.Request.URL.Path)
+		testSignature(c, t)
+		assert.Equal(t, "2", c.Query("page"), "page query param is not equal")
+		res := []*Role{{
+			TenantBase:  TenantBase{Base: Base{Id: "rol_0b3f5c1d2e4a6b8c9d0e1f2a3b4c5d6e"}, Tenant: c.Param("tenantId")},
+			Name:        "admin",
+			Permissions: []string{"tenant:read", "tenant:write"},
+		}}
+		c.JSON(http.StatusOK, newSuccessPagingResponse(res, 1))
+	})
+
+	roles, err := client.ForTenant("ten_ea87af463d9fc38203690805c1c1fa33").Role.List(context.TODO(), &RoleListParams{Page: 2})
+	if err != nil {
+		assert.NoError(t, err, "role list error")
+		return
+	}
+	assert.Len(t, roles.Items, 1, "roles length should be 1")
+	assert.Equal(t, PageInfo{Page: 2}, roles.PageInfo, "page info is not equal")
+	role := roles.Items[0]
+	assert.Equal(t, "admin", role.Name)
+	assert.Equal(t, "ten_ea87af463d9fc38203690805c1c1fa33", role.Tenant, "role tenant is not equal")
+	assert.Equal(t, []string{"tenant:read", "tenant:write"}, role.Permissions)
+}
+
+func TestRoleService_Scoped(t *testing.T) {
+	setup()
+	defer teardown()
+	var paths []string
+	handler := func(c *gin.Context) {
+		testSignature(c, t)
+		paths = append(paths, c.Request.Method+" "+c.Request.URL.Path)
+		c.JSON(http.StatusOK, newSuccessResponse(&Role{Name: "admin"}))
+	}
+	router.GET("/tenants/:tenantId/roles/:roleId", handler)
+	router.POST("/tenants/:tenantId/roles", handler)
+	router.PUT("/tenants/:tenantId/roles/:roleId", handler)
+	router.DELETE("/tenants/:tenantId/roles/:roleId", handler)
+
+	first := client.ForTenant("ten_first")
+	second := client.ForTenant("ten_second")
+	_, err := first.Role.Get(context.TODO(), "rol_1")
+	assert.NoError(t, err)
+	_, err = second.Role.Create(context.TODO(), &RoleCreateRequest{Name: "admin"})
+	assert.NoError(t, err)
+	_, err = first.Role.Update(context.TODO(), "rol_1", &RoleUpdateRequest{Id: "rol_1", Name: "admin"})
+	assert.NoError(t, err)
+	assert.NoError(t, second.Role.Delete(context.TODO(), "rol_2"))
+
+	assert.Equal(t, []string{
+		"GET /tenants/ten_first/roles/rol_1",
+		"POST /tenants/ten_second/roles",
+		"PUT /tenants/ten_first/roles/rol_1",
+		"DELETE /tenants/ten_second/roles/rol_2",
+	}, paths, "paths should be scoped to the tenant")
+}
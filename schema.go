@@ -0,0 +1,19 @@
+package zerogate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Schema get the raw OpenAPI document describing the API
+func (c *Client) Schema(ctx context.Context, opts ...RequestOption) (json.RawMessage, error) {
+	res, err := c.get(ctx, "/openapi.json", nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(res.Body) {
+		return nil, errors.New("failed to unmarshal schema JSON data: invalid JSON")
+	}
+	return json.RawMessage(res.Body), nil
+}
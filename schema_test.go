@@ -0,0 +1,44 @@
+package zerogate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Schema(t *testing.T) {
+	setup()
+	defer teardown()
+	document := `{"openapi":"3.0.3","info":{"title":"ZeroGate API","version":"1.0.0"},"paths":{}}`
+	router.GET("/openapi.json", func(c *gin.Context) {
+		testSignature(c, t)
+		c.Data(http.StatusOK, "application/json", []byte(document))
+	})
+
+	schema, err := client.Schema(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "schema error")
+		return
+	}
+	assert.JSONEq(t, document, string(schema))
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+	}
+	assert.NoError(t, json.Unmarshal(schema, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+}
+
+func TestClient_SchemaInvalid(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/plain", []byte("openapi: 3.0.3"))
+	})
+
+	_, err := client.Schema(context.TODO())
+	assert.Error(t, err, "non JSON schema should fail")
+}
@@ -0,0 +1,26 @@
+package zerogate
+
+// TenantScope services for the resources of a single tenant, their paths are
+// nested below /tenants/{tenantId}.
+type TenantScope struct {
+	Role *RoleService
+}
+
+// tenantService is embedded by the tenant scoped services.
+type tenantService struct {
+	client   *Client
+	tenantId string
+}
+
+// path returns the path of a resource of the tenant.
+func (s *tenantService) path(resource string) string {
+	return "/tenants/" + s.tenantId + resource
+}
+
+// ForTenant returns the services scoped to the tenant.
+func (c *Client) ForTenant(tenantId string) *TenantScope {
+	scope := &tenantService{client: c, tenantId: tenantId}
+	return &TenantScope{
+		Role: (*RoleService)(scope),
+	}
+}
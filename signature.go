@@ -0,0 +1,44 @@
+package zerogate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+)
+
+// SignatureAlgorithm HMAC algorithm used to sign requests
+type SignatureAlgorithm string
+
+const (
+	SignatureHMACSHA256 SignatureAlgorithm = "HMAC-SHA256"
+	SignatureHMACSHA512 SignatureAlgorithm = "HMAC-SHA512"
+)
+
+// hash returns the hash constructor of the algorithm, nil when unsupported.
+func (a SignatureAlgorithm) hash() func() hash.Hash {
+	switch a {
+	case SignatureHMACSHA256:
+		return sha256.New
+	case SignatureHMACSHA512:
+		return sha512.New
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA512 signature of a request, as sent
+// in the Signature parameter of the Authorization header with
+// "APIKey=<key>, Signature=<signature>, Nonce=<nonce>". The body is nil for
+// requests without one. Nonces must be unique, see NonceGenerator.
+func Sign(secret, method, path, nonce string, body []byte) string {
+	return sign(SignatureHMACSHA512, secret, method, path, nonce, body)
+}
+
+// sign returns the hex encoded signature of a request with the algorithm.
+func sign(algorithm SignatureAlgorithm, secret, method, path, nonce string, body []byte) string {
+	// Create an HMAC hash using the API secret as the key
+	h := hmac.New(algorithm.hash(), []byte(secret))
+	h.Write(signedMessage(method, path, nonce, body))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package zerogate
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureAlgorithmOption(t *testing.T) {
+	tests := []struct {
+		algorithm SignatureAlgorithm
+		newHash   func() hash.Hash
+		tag       string
+	}{
+		{algorithm: SignatureHMACSHA256, newHash: sha256.New, tag: ", Algorithm=HMAC-SHA256"},
+		{algorithm: SignatureHMACSHA512, newHash: sha512.New},
+	}
+	for _, test := range tests {
+		t.Run(string(test.algorithm), func(t *testing.T) {
+			setup(WithSignatureAlgorithm(test.algorithm))
+			defer teardown()
+			router.POST("/signed", func(c *gin.Context) {
+				header := c.Request.Header.Get("Authorization")
+				params, err := parseAuthorization(header)
+				if err != nil {
+					assert.NoError(t, err, "invalid Authorization header")
+					return
+				}
+				if test.tag == "" {
+					assert.NotContains(t, header, "Algorithm=", "default algorithm should not be tagged")
+				} else {
+					assert.True(t, strings.HasSuffix(header, test.tag), "header %q should be tagged with the algorithm", header)
+				}
+				h := hmac.New(test.newHash, []byte(testApiSecret))
+				h.Write([]byte("POST/signed" + params["Nonce"] + `{"name":"Test"}`))
+				assert.Equal(t, hex.EncodeToString(h.Sum(nil)), params["Signature"], "signature mismatch")
+				c.JSON(http.StatusOK, "ok")
+			})
+
+			_, err := client.post(context.Background(), "/signed", nil, []byte(`{"name":"Test"}`), nil)
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err := New(testApiKey, testApiSecret, WithSignatureAlgorithm("HMAC-MD5"))
+	assert.Error(t, err, "unsupported algorithm should be rejected")
+}
+
+type timestampNonceGenerator struct{}
+
+func (timestampNonceGenerator) Nonce(timestamp int64) string {
+	return strconv.FormatInt(timestamp, 10)
+}
+
+func TestSignRequestPinnedClock(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	setup(withClock(func() time.Time { return now }), WithNonceGenerator(timestampNonceGenerator{}))
+	defer teardown()
+	var header string
+	router.GET("/tenants", func(c *gin.Context) {
+		header = c.Request.Header.Get("Authorization")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/tenants", nil, nil)
+	assert.NoError(t, err)
+	h := hmac.New(sha512.New, []byte(testApiSecret))
+	h.Write([]byte("GET/tenants1700000000"))
+	assert.Equal(t, "APIKey="+testApiKey+", Signature="+hex.EncodeToString(h.Sum(nil))+", Nonce=1700000000", header)
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret, method, path, nonce string
+		body                        []byte
+		expected                    string
+	}{
+		{
+			secret: testApiSecret, method: http.MethodGet, path: "/tenants", nonce: "1700000000",
+			expected: "90369744a8b21ac5a2aa3b10db9563a596f3c6753634273771f69767ac39f2cedfa69978369eb08cdee7818141170d777c7fdbe1777aec888cb8131449c4f6db",
+		},
+		{
+			secret: testApiSecret, method: http.MethodPost, path: "/tenants", nonce: "1700000000.9f86d081884c7d65", body: []byte(`{"name":"Test"}`),
+			expected: "65acece9d530908e409df31c7bf95f57dc5e1f668b877f260af85bf5acde67a3897c64709aff3a0c1f1e2b0f851a1b097452d4984e49f69c89fd3b16e1fa5853",
+		},
+		{
+			secret: "secret", method: http.MethodDelete, path: "/tenants/ten_1", nonce: "1",
+			expected: "b1420a96d4809ba9a6f2ab21ddbe243f7fb01da53d028c59fecaacab2eed04abf9b5108b73600ea317f5482c1c84a0248d0437a529d9cc29ad4f6d497cde32f6",
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Sign(test.secret, test.method, test.path, test.nonce, test.body), "%s %s", test.method, test.path)
+	}
+	assert.NotEqual(t, Sign(testApiSecret, http.MethodGet, "/tenants", "1", nil), Sign(testApiSecret, http.MethodGet, "/tenants", "2", nil))
+}
+
+func TestSign_ManualRequest(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/custom", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	// a request built by hand and signed with Sign is accepted like one built by the client
+	body := []byte(`{"name":"Test"}`)
+	nonce := randomNonceGenerator{}.Nonce(time.Now().Unix())
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/custom", bytes.NewReader(body))
+	req.Header.Set("Authorization", "APIKey="+testApiKey+", Signature="+Sign(testApiSecret, req.Method, req.URL.Path, nonce, body)+", Nonce="+nonce)
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
@@ -2,8 +2,12 @@ package zerogate
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
 )
 
 // Tenant ZeroGate tenant
@@ -31,44 +35,283 @@ type TenantUpdateRequest struct {
 	Description string `json:"description"`
 }
 
-// Create creates a new tenant
-func (t *TenantService) Create(ctx context.Context, request *TenantCreateRequest) (*Tenant, error) {
-	res, err := t.client.post(ctx, "/tenants", nil, request, nil)
+// ImportResult bulk import result
+type ImportResult struct {
+	Imported int                  `json:"imported"`
+	Failed   int                  `json:"failed"`
+	Records  []ImportRecordResult `json:"records"`
+}
+
+// ImportRecordResult result of a single imported record
+type ImportRecordResult struct {
+	// Line is the 1-based line number of the record in the stream.
+	Line  int    `json:"line"`
+	Id    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// TenantListParams tenant list parameters
+type TenantListParams struct {
+	// Page is the 1-based page number, the server default is used when zero.
+	Page int
+	// PageSize is the number of tenants per page, the server default is used when zero.
+	PageSize int
+	// SortBy is the field to sort by, the server default is used when empty.
+	SortBy string
+	// Order is the sort order, OrderAsc or OrderDesc.
+	Order string
+	// Search filters tenants by name or keyword, all tenants are listed when
+	// empty.
+	Search string
+}
+
+func (p *TenantListParams) query() map[string][]string {
+	if p == nil {
+		return listQuery(0, 0, "", "")
+	}
+	query := listQuery(p.Page, p.PageSize, p.SortBy, p.Order)
+	if search := strings.TrimSpace(p.Search); search != "" {
+		query["q"] = []string{search}
+	}
+	return query
+}
+
+func (p *TenantListParams) validate() error {
+	if p == nil {
+		return nil
+	}
+	return validateOrder(p.Order)
+}
+
+// Create creates a new tenant, the returned tenant is nil when the server
+// honors PreferMinimal with an empty response
+func (t *TenantService) Create(ctx context.Context, request *TenantCreateRequest, opts ...RequestOption) (*Tenant, error) {
+	res, err := t.client.post(ctx, "/tenants", nil, request, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Tenant](t.client, res, "tenant")
+}
+
+// Get get the tenant
+func (t *TenantService) Get(ctx context.Context, tenantId string, opts ...RequestOption) (*Tenant, error) {
+	res, err := t.client.get(ctx, "/tenants/"+tenantId, nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return decodeData[*Tenant](t.client, res, "tenant")
+}
+
+// List get tenants, params may be nil to use the server defaults
+func (t *TenantService) List(ctx context.Context, params *TenantListParams, opts ...RequestOption) (*List[*Tenant], error) {
+	err := params.validate()
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.client.get(ctx, "/tenants", params.query(), nil, opts...)
 	if err != nil {
 		return nil, err
 	}
-	var r SuccessResponse[*Tenant]
-	err = json.Unmarshal(res.Body, &r)
+	list, err := decodeList[*Tenant](t.client, res, "tenant")
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal tenant JSON data: %w", err)
+		return nil, err
+	}
+	if params != nil {
+		list.PageInfo = PageInfo{Page: params.Page, PageSize: params.PageSize}
 	}
-	return r.Data, nil
+	return list, nil
 }
 
-// List get all tenants
-func (t *TenantService) List(ctx context.Context) ([]*Tenant, int64, error) {
-	res, err := t.client.get(ctx, "/tenants", nil, nil)
+// ListPage get a page of tenants like List, along with the requested paging
+func (t *TenantService) ListPage(ctx context.Context, params *TenantListParams, opts ...RequestOption) (*Page[*Tenant], error) {
+	list, err := t.List(ctx, params, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(list), nil
+}
+
+// ListLenient get tenants like List, but skips tenants that fail to decode
+// instead of failing the whole page. Skipped tenants are logged through the
+// client logger and their count is returned.
+func (t *TenantService) ListLenient(ctx context.Context, params *TenantListParams, opts ...RequestOption) (*List[*Tenant], int, error) {
+	err := params.validate()
+	if err != nil {
+		return nil, 0, err
+	}
+	res, err := t.client.get(ctx, "/tenants", params.query(), nil, opts...)
 	if err != nil {
 		return nil, 0, err
 	}
-	var r SuccessPagingResponse[*Tenant]
-	err = json.Unmarshal(res.Body, &r)
+	if res.NotModified {
+		return nil, 0, ErrNotModified
+	}
+	list := &List[*Tenant]{}
+	skipped := 0
+	if !res.empty() {
+		var r *SuccessPagingResponse[*Tenant]
+		r, skipped, err = decodePageLenient[*Tenant](t.client.logger, res.Body, t.client.decode)
+		if err != nil {
+			return nil, 0, err
+		}
+		list.Items = r.Data
+		list.Total = r.Total
+	}
+	if params != nil {
+		list.PageInfo = PageInfo{Page: params.Page, PageSize: params.PageSize}
+	}
+	return list, skipped, nil
+}
+
+// ListAsMap get tenants keyed by their id, params may be nil to use the
+// server defaults
+func (t *TenantService) ListAsMap(ctx context.Context, params *TenantListParams, opts ...RequestOption) (map[string]*Tenant, int64, error) {
+	list, err := t.List(ctx, params, opts...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to unmarshal tenant JSON data: %w", err)
+		return nil, 0, err
+	}
+	tenants := make(map[string]*Tenant, len(list.Items))
+	for _, tenant := range list.Items {
+		if _, ok := tenants[tenant.Id]; ok {
+			return nil, 0, fmt.Errorf("duplicate tenant id %q in list response", tenant.Id)
+		}
+		tenants[tenant.Id] = tenant
+	}
+	return tenants, list.Total, nil
+}
+
+// ListAll get all tenants by paging through the list endpoint. If a page
+// fails, the tenants collected so far are returned along with the error.
+func (t *TenantService) ListAll(ctx context.Context, opts ...RequestOption) ([]*Tenant, error) {
+	var all []*Tenant
+	it := t.ListIterator(opts...)
+	for it.Next(ctx) {
+		all = append(all, it.Value())
 	}
-	return r.Data, r.Total, nil
+	return all, it.Err()
+}
+
+// ListIterator returns an iterator over all tenants, fetching pages on demand.
+func (t *TenantService) ListIterator(opts ...RequestOption) *Iterator[*Tenant] {
+	return NewIterator(func(ctx context.Context, page int) ([]*Tenant, int64, error) {
+		list, err := t.List(ctx, &TenantListParams{Page: page, PageSize: listAllPageSize}, opts...)
+		if err != nil {
+			return nil, 0, err
+		}
+		return list.Items, list.Total, nil
+	})
 }
 
-// Update updates the tenant
-func (t *TenantService) Update(ctx context.Context, tenantId string, request *TenantUpdateRequest) (*Tenant, error) {
-	res, err := t.client.put(ctx, "/tenants/"+tenantId, nil, request, nil)
+// Update updates the tenant, the returned tenant is nil when the server
+// honors PreferMinimal with an empty response
+func (t *TenantService) Update(ctx context.Context, tenantId string, request *TenantUpdateRequest, opts ...RequestOption) (*Tenant, error) {
+	res, err := t.client.put(ctx, "/tenants/"+tenantId, nil, request, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
-	var r SuccessResponse[*Tenant]
-	err = json.Unmarshal(res.Body, &r)
+	return decodeData[*Tenant](t.client, res, "tenant")
+}
+
+// Delete deletes the tenant
+func (t *TenantService) Delete(ctx context.Context, tenantId string, opts ...RequestOption) error {
+	_, err := t.client.delete(ctx, "/tenants/"+tenantId, nil, nil, opts...)
+	return err
+}
+
+// ImportStream imports tenants from r, which must yield one JSON encoded
+// TenantCreateRequest per line (NDJSON). The stream is sent with chunked
+// transfer encoding and is not retried, as it cannot be replayed. Only the
+// header and timeout request options apply.
+func (t *TenantService) ImportStream(ctx context.Context, r io.Reader, opts ...RequestOption) (ImportResult, error) {
+	options := newRequestOptions(opts)
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	headers := combineHeaders(options.headers, http.Header{"Content-Type": {"application/x-ndjson"}})
+	res, err := t.client.sendStream(ctx, http.MethodPost, "/tenants/import", r, headers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal tenant JSON data: %w", err)
+		return ImportResult{}, err
+	}
+	return decodeData[ImportResult](t.client, res, "import result")
+}
+
+// BatchDelete deletes the tenants concurrently and returns the result of each
+// delete keyed by tenant id, successful deletes map to nil. The returned error
+// is only set when the batch could not be started.
+func (t *TenantService) BatchDelete(ctx context.Context, ids []string, opts ...RequestOption) (map[string]error, error) {
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("tenant id must not be empty")
+		}
+		if _, ok := seen[id]; ok {
+			return nil, fmt.Errorf("duplicate tenant id %q", id)
+		}
+		seen[id] = struct{}{}
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(ids))
+	sem := make(chan struct{}, batchConcurrency)
+	for _, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mutex.Lock()
+			results[id] = ctx.Err()
+			mutex.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := t.Delete(ctx, id, opts...)
+			mutex.Lock()
+			results[id] = err
+			mutex.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// CreateBatch creates the tenants with at most concurrency requests in
+// flight, batchConcurrency when zero or less. The results and errors are in
+// the order of the requests, a failed create has a nil tenant and its error
+// set. Once ctx is done no further creates are started and the remaining
+// requests fail with the context error.
+func (t *TenantService) CreateBatch(ctx context.Context, reqs []*TenantCreateRequest, concurrency int, opts ...RequestOption) ([]*Tenant, []error) {
+	if concurrency <= 0 {
+		concurrency = batchConcurrency
+	}
+	tenants := make([]*Tenant, len(reqs))
+	errs := make([]error, len(reqs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, req := range reqs {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, req *TenantCreateRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// each goroutine writes only its own index
+			tenants[i], errs[i] = t.Create(ctx, req, opts...)
+		}(i, req)
 	}
-	return r.Data, nil
+	wg.Wait()
+	return tenants, errs
 }
@@ -1,11 +1,23 @@
 package zerogate
 
 import (
+	"bufio"
 	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTenantService_Create(t *testing.T) {
@@ -43,6 +55,26 @@ func TestTenantService_Create(t *testing.T) {
 	assert.NotEmpty(t, tenant.Organization, "tenant organization is empty")
 }
 
+func TestTenantService_Get(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		assert.Equal(t, "/tenants/ten_ea87af463d9fc38203690805c1c1fa33", c.Request.URL.Path)
+		testSignature(c, t)
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{
+			Base: Base{Id: c.Param("tenantId")},
+			Name: "Test",
+		}))
+	})
+	tenant, err := client.Tenant.Get(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	if err != nil {
+		assert.NoError(t, err, "tenant get error")
+		return
+	}
+	assert.Equal(t, "ten_ea87af463d9fc38203690805c1c1fa33", tenant.Id)
+	assert.Equal(t, "Test", tenant.Name)
+}
+
 func TestTenantService_List(t *testing.T) {
 	setup()
 	defer teardown()
@@ -59,14 +91,15 @@ func TestTenantService_List(t *testing.T) {
 		c.JSON(http.StatusOK, newSuccessPagingResponse(res, 1))
 	})
 
-	tenants, total, err := client.Tenant.List(context.TODO())
+	tenants, err := client.Tenant.List(context.TODO(), nil)
 	if err != nil {
 		assert.NoError(t, err, "tenant creation error")
 		return
 	}
-	assert.Equal(t, len(tenants), 1, "tenants length should be 1")
-	assert.Equal(t, total, int64(1), "tenants length should be 1")
-	tenant := tenants[0]
+	assert.Equal(t, len(tenants.Items), 1, "tenants length should be 1")
+	assert.Equal(t, tenants.Total, int64(1), "tenants length should be 1")
+	assert.Equal(t, PageInfo{}, tenants.PageInfo, "page info should be empty")
+	tenant := tenants.Items[0]
 	assert.Equal(t, "Test", tenant.Name, "tenant name is not equal")
 	assert.Equal(t, "test tenant", tenant.Description, "tenant description is not equal")
 	assert.NotEmpty(t, tenant.Id, "tenant id is empty")
@@ -108,3 +141,549 @@ func TestTenantService_Update(t *testing.T) {
 	assert.Equal(t, req.Id, tenant.Id, "tenant id is not equal")
 	assert.NotEmpty(t, tenant.Organization, "tenant organization is empty")
 }
+
+func TestTenantService_Delete(t *testing.T) {
+	setup()
+	defer teardown()
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		assert.Equal(t, http.MethodDelete, c.Request.Method, "Expected method 'DELETE', got %s", c.Request.Method)
+		assert.Equal(t, "/tenants/"+c.Param("tenantId"), c.Request.URL.Path)
+		testSignature(c, t)
+		if c.Param("tenantId") != "ten_ea87af463d9fc38203690805c1c1fa33" {
+			c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "tenant not found"))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(true))
+	})
+
+	err := client.Tenant.Delete(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	assert.NoError(t, err, "tenant delete error")
+
+	err = client.Tenant.Delete(context.TODO(), "ten_missing")
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr, "error should be an *Error") {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, "tenant not found", apiErr.Response.ErrorMessage)
+	}
+}
+
+func TestTenantService_EmptyResponses(t *testing.T) {
+	setup()
+	defer teardown()
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/tenants", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.PUT("/tenants/:tenantId", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	err := client.Tenant.Delete(context.TODO(), "ten_1")
+	assert.NoError(t, err, "204 delete should succeed")
+
+	tenant, err := client.Tenant.Get(context.TODO(), "ten_1")
+	assert.NoError(t, err, "empty 200 body should not be decoded")
+	assert.Nil(t, tenant)
+
+	list, err := client.Tenant.List(context.TODO(), nil)
+	if assert.NoError(t, err, "empty 200 list body should not be decoded") {
+		assert.Empty(t, list.Items)
+		assert.Zero(t, list.Total)
+	}
+
+	list, skipped, err := client.Tenant.ListLenient(context.TODO(), nil)
+	if assert.NoError(t, err, "empty 200 lenient list body should not be decoded") {
+		assert.Empty(t, list.Items)
+		assert.Zero(t, skipped)
+	}
+
+	tenant, err = client.Tenant.Update(context.TODO(), "ten_1", &TenantUpdateRequest{Name: "Test"})
+	assert.NoError(t, err, "204 update should succeed")
+	assert.Nil(t, tenant)
+}
+
+func TestTenantService_ListPaging(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "2", c.Query("page"), "page query param is not equal")
+		assert.Equal(t, "10", c.Query("page_size"), "page_size query param is not equal")
+		res := []*Tenant{{
+			Base: Base{Id: "ten_ea87af463d9fc38203690805c1c1fa33"},
+			Name: "Test",
+		}}
+		c.JSON(http.StatusOK, newSuccessPagingResponse(res, 11))
+	})
+
+	tenants, err := client.Tenant.List(context.TODO(), &TenantListParams{Page: 2, PageSize: 10})
+	if err != nil {
+		assert.NoError(t, err, "tenant list error")
+		return
+	}
+	assert.Len(t, tenants.Items, 1, "tenants length should be 1")
+	assert.Equal(t, int64(11), tenants.Total, "tenants total should be 11")
+	assert.Equal(t, PageInfo{Page: 2, PageSize: 10}, tenants.PageInfo, "page info is not equal")
+}
+
+func TestTenantService_ListPage(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants", servePages(t, testTenants(25), 25))
+
+	page, err := client.Tenant.ListPage(context.TODO(), &TenantListParams{Page: 2, PageSize: 10})
+	if assert.NoError(t, err, "tenant list error") {
+		assert.Len(t, page.Items, 10)
+		assert.Equal(t, int64(25), page.Total)
+		assert.Equal(t, 2, page.Page)
+		assert.Equal(t, 10, page.PageSize)
+		assert.True(t, page.HasNext(), "page 2 of 3 should have a next page")
+	}
+	page, err = client.Tenant.ListPage(context.TODO(), &TenantListParams{Page: 3, PageSize: 10})
+	if assert.NoError(t, err, "tenant list error") {
+		assert.Len(t, page.Items, 5)
+		assert.False(t, page.HasNext(), "last page should have no next page")
+	}
+}
+
+func TestTenantListParams_Query(t *testing.T) {
+	var params *TenantListParams
+	assert.Empty(t, params.query(), "nil params should produce an empty query")
+	assert.Empty(t, (&TenantListParams{}).query(), "zero params should produce an empty query")
+	assert.Equal(t, map[string][]string{"page": {"3"}, "page_size": {"25"}},
+		(&TenantListParams{Page: 3, PageSize: 25}).query())
+	assert.Equal(t, map[string][]string{"sort_by": {"created"}, "order": {"asc"}},
+		(&TenantListParams{SortBy: "created", Order: OrderAsc}).query())
+}
+
+func TestTenantService_ListSearch(t *testing.T) {
+	var rawQuery string
+	setup(WithRequestHook(func(req *http.Request) {
+		rawQuery = req.URL.RawQuery
+	}))
+	defer teardown()
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, newSuccessPagingResponse(testTenants(1), 1))
+	})
+
+	tenants, err := client.Tenant.List(context.TODO(), &TenantListParams{Search: "  acme & co "})
+	if assert.NoError(t, err, "tenant list error") {
+		assert.Len(t, tenants.Items, 1)
+		assert.Equal(t, int64(1), tenants.Total)
+	}
+	assert.Equal(t, "q=acme+%26+co", rawQuery, "search should be trimmed and encoded")
+
+	for _, search := range []string{"", "   "} {
+		_, err = client.Tenant.List(context.TODO(), &TenantListParams{Search: search})
+		assert.NoError(t, err)
+		assert.Empty(t, rawQuery, "empty search should send no query param")
+	}
+}
+
+func TestTenantService_ListSorting(t *testing.T) {
+	setup()
+	defer teardown()
+	calls := 0
+	router.GET("/tenants", func(c *gin.Context) {
+		calls++
+		testSignature(c, t)
+		assert.Equal(t, "name", c.Query("sort_by"), "sort_by query param is not equal")
+		assert.Equal(t, "desc", c.Query("order"), "order query param is not equal")
+		c.JSON(http.StatusOK, newSuccessPagingResponse(testTenants(1), 1))
+	})
+
+	tenants, err := client.Tenant.List(context.TODO(), &TenantListParams{SortBy: "name", Order: OrderDesc})
+	if assert.NoError(t, err, "tenant list error") {
+		assert.Len(t, tenants.Items, 1)
+	}
+
+	_, err = client.Tenant.List(context.TODO(), &TenantListParams{SortBy: "name", Order: "sideways"})
+	assert.ErrorContains(t, err, "invalid sort order", "invalid order should be rejected")
+	_, _, err = client.Tenant.ListLenient(context.TODO(), &TenantListParams{Order: "DESC"})
+	assert.Error(t, err, "invalid order should be rejected")
+	assert.Equal(t, 1, calls, "invalid order should be rejected before the request is sent")
+}
+
+func testTenants(n int) []*Tenant {
+	tenants := make([]*Tenant, n)
+	for i := range tenants {
+		tenants[i] = &Tenant{
+			Base: Base{Id: fmt.Sprintf("ten_%032d", i)},
+			Name: fmt.Sprintf("Test %d", i),
+		}
+	}
+	return tenants
+}
+
+// servePages serves the given tenants using the page and page_size query
+// params, reporting total as the paging total.
+func servePages(t *testing.T, tenants []*Tenant, total int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		testSignature(c, t)
+		page, _ := strconv.Atoi(c.Query("page"))
+		pageSize, _ := strconv.Atoi(c.Query("page_size"))
+		start := (page - 1) * pageSize
+		end := start + pageSize
+		if start > len(tenants) {
+			start = len(tenants)
+		}
+		if end > len(tenants) {
+			end = len(tenants)
+		}
+		c.JSON(http.StatusOK, newSuccessPagingResponse(tenants[start:end], total))
+	}
+}
+
+func TestTenantService_ListAll(t *testing.T) {
+	setup()
+	defer teardown()
+	tenants := testTenants(250)
+	router.GET("/tenants", servePages(t, tenants, int64(len(tenants))))
+
+	all, err := client.Tenant.ListAll(context.TODO())
+	if err != nil {
+		assert.NoError(t, err, "tenant list all error")
+		return
+	}
+	assert.Equal(t, tenants, all, "all tenants should be returned in order")
+}
+
+func TestTenantService_ListAllInconsistentTotal(t *testing.T) {
+	setup()
+	defer teardown()
+	requests := 0
+	router.GET("/tenants", func(c *gin.Context) {
+		requests++
+		// always return a full first page and a total larger than the data
+		c.JSON(http.StatusOK, newSuccessPagingResponse(testTenants(listAllPageSize), 1000))
+	})
+
+	all, err := client.Tenant.ListAll(context.TODO())
+	assert.NoError(t, err, "tenant list all error")
+	assert.Equal(t, 10, requests, "paging should stop after the pages implied by the first total")
+	assert.Len(t, all, 1000)
+}
+
+func TestTenantService_ListAllPartial(t *testing.T) {
+	setup()
+	defer teardown()
+	tenants := testTenants(250)
+	pages := servePages(t, tenants, int64(len(tenants)))
+	router.GET("/tenants", func(c *gin.Context) {
+		if c.Query("page") == "2" {
+			c.JSON(http.StatusInternalServerError, newErrorsResponse(http.StatusInternalServerError, "internal error"))
+			return
+		}
+		pages(c)
+	})
+
+	all, err := client.Tenant.ListAll(context.TODO())
+	assert.Error(t, err, "failing page should return an error")
+	assert.Equal(t, tenants[:listAllPageSize], all, "first page should be returned")
+}
+
+func TestTenantService_ListAllCanceled(t *testing.T) {
+	setup()
+	defer teardown()
+	ctx, cancel := context.WithCancel(context.Background())
+	tenants := testTenants(250)
+	pages := servePages(t, tenants, int64(len(tenants)))
+	router.GET("/tenants", func(c *gin.Context) {
+		if c.Query("page") == "2" {
+			cancel()
+			time.Sleep(100 * time.Millisecond)
+		}
+		pages(c)
+	})
+
+	all, err := client.Tenant.ListAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, all, listAllPageSize, "first page should be returned")
+}
+
+func TestTenantService_BatchDelete(t *testing.T) {
+	setup()
+	defer teardown()
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		if c.Param("tenantId") == "ten_missing" {
+			c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "tenant not found"))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(true))
+	})
+
+	ids := []string{"ten_1", "ten_missing", "ten_2", "ten_3", "ten_4", "ten_5", "ten_6"}
+	results, err := client.Tenant.BatchDelete(context.TODO(), ids)
+	if err != nil {
+		assert.NoError(t, err, "tenant batch delete error")
+		return
+	}
+	assert.Len(t, results, len(ids), "every id should have a result")
+	for _, id := range ids {
+		if id == "ten_missing" {
+			var apiErr *Error
+			if assert.ErrorAs(t, results[id], &apiErr) {
+				assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+			}
+			continue
+		}
+		assert.Contains(t, results, id)
+		assert.NoError(t, results[id], "tenant %s delete error", id)
+	}
+
+	_, err = client.Tenant.BatchDelete(context.TODO(), []string{"ten_1", ""})
+	assert.Error(t, err, "empty id should be rejected")
+	_, err = client.Tenant.BatchDelete(context.TODO(), []string{"ten_1", "ten_1"})
+	assert.Error(t, err, "duplicate ids should be rejected")
+}
+
+func TestTenantService_Prefer(t *testing.T) {
+	setup(WithPrefer(PreferMinimal))
+	defer teardown()
+	handler := func(c *gin.Context) {
+		testSignature(c, t)
+		if c.Request.Header.Get("Prefer") == PreferMinimal {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		assert.Equal(t, PreferRepresentation, c.Request.Header.Get("Prefer"))
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{
+			Base: Base{Id: "ten_ea87af463d9fc38203690805c1c1fa33"},
+			Name: "Test",
+		}))
+	}
+	router.POST("/tenants", handler)
+	router.PUT("/tenants/:tenantId", handler)
+
+	tenant, err := client.Tenant.Create(context.TODO(), &TenantCreateRequest{Name: "Test"})
+	assert.NoError(t, err, "minimal create should not fail on the empty body")
+	assert.Nil(t, tenant)
+	tenant, err = client.Tenant.Update(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33", &TenantUpdateRequest{Name: "Test"})
+	assert.NoError(t, err, "minimal update should not fail on the empty body")
+	assert.Nil(t, tenant)
+
+	ctx := WithCallPrefer(context.TODO(), PreferRepresentation)
+	tenant, err = client.Tenant.Create(ctx, &TenantCreateRequest{Name: "Test"})
+	if assert.NoError(t, err) && assert.NotNil(t, tenant) {
+		assert.Equal(t, "Test", tenant.Name)
+	}
+	tenant, err = client.Tenant.Update(ctx, "ten_ea87af463d9fc38203690805c1c1fa33", &TenantUpdateRequest{Name: "Test"})
+	if assert.NoError(t, err) && assert.NotNil(t, tenant) {
+		assert.Equal(t, "ten_ea87af463d9fc38203690805c1c1fa33", tenant.Id)
+	}
+}
+
+func TestTenantService_DeleteIdempotent(t *testing.T) {
+	setup(WithIdempotentDelete())
+	defer teardown()
+	deleted := make(map[string]bool)
+	router.DELETE("/tenants/:tenantId", func(c *gin.Context) {
+		testSignature(c, t)
+		if deleted[c.Param("tenantId")] {
+			c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "tenant not found"))
+			return
+		}
+		deleted[c.Param("tenantId")] = true
+		c.JSON(http.StatusOK, newSuccessResponse(true))
+	})
+	router.DELETE("/forbidden/:tenantId", func(c *gin.Context) {
+		c.JSON(http.StatusForbidden, newErrorsResponse(http.StatusForbidden, "forbidden"))
+	})
+
+	err := client.Tenant.Delete(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	assert.NoError(t, err, "first delete should succeed")
+	err = client.Tenant.Delete(context.TODO(), "ten_ea87af463d9fc38203690805c1c1fa33")
+	assert.NoError(t, err, "second delete should be treated as success")
+	res, err := client.Do(context.TODO(), http.MethodDelete, "/tenants/ten_ea87af463d9fc38203690805c1c1fa33", nil, nil)
+	if assert.NoError(t, err, "delete sent with Do should be treated as success") {
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	}
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "tenant not found"))
+	})
+	_, err = client.Do(context.TODO(), http.MethodGet, "/tenants/ten_ea87af463d9fc38203690805c1c1fa33", nil, nil)
+	assert.True(t, IsNotFound(err), "only deletes should be treated as success")
+
+	_, err = client.delete(context.TODO(), "/forbidden/ten_ea87af463d9fc38203690805c1c1fa33", nil, nil)
+	assert.Error(t, err, "other errors should still be returned")
+}
+
+func TestTenantService_ListAsMap(t *testing.T) {
+	setup()
+	defer teardown()
+	tenants := testTenants(3)
+	router.GET("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		if c.Query("page") == "2" {
+			c.JSON(http.StatusOK, newSuccessPagingResponse([]*Tenant{tenants[0], tenants[0]}, 2))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessPagingResponse(tenants, 3))
+	})
+
+	byId, total, err := client.Tenant.ListAsMap(context.TODO(), nil)
+	if err != nil {
+		assert.NoError(t, err, "tenant list error")
+		return
+	}
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, byId, len(tenants))
+	for id, tenant := range byId {
+		assert.Equal(t, id, tenant.Id, "map key should be the tenant id")
+	}
+
+	_, _, err = client.Tenant.ListAsMap(context.TODO(), &TenantListParams{Page: 2})
+	assert.Error(t, err, "duplicate ids should fail")
+}
+
+func TestTenantService_ImportStream(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/tenants/import", func(c *gin.Context) {
+		assert.Equal(t, "application/x-ndjson", c.Request.Header.Get("Content-Type"))
+		assert.Equal(t, []string{"chunked"}, c.Request.TransferEncoding, "body should be streamed")
+		params, err := parseAuthorization(c.Request.Header.Get("Authorization"))
+		if err != nil {
+			assert.NoError(t, err, "invalid Authorization header")
+			return
+		}
+		h := hmac.New(sha512.New, []byte(testApiSecret))
+		h.Write([]byte(c.Request.Method + c.Request.URL.Path + params["Nonce"]))
+		assert.Equal(t, hex.EncodeToString(h.Sum(nil)), params["Signature"], "header signature mismatch")
+
+		body := hmac.New(sha512.New, []byte(testApiSecret))
+		body.Write([]byte(params["Nonce"]))
+		var result ImportResult
+		scanner := bufio.NewScanner(io.TeeReader(c.Request.Body, body))
+		for line := 1; scanner.Scan(); line++ {
+			record := ImportRecordResult{Line: line}
+			var request TenantCreateRequest
+			if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || request.Name == "" {
+				record.Error = "invalid tenant"
+				result.Failed++
+			} else {
+				record.Id = fmt.Sprintf("ten_%d", line)
+				result.Imported++
+			}
+			result.Records = append(result.Records, record)
+		}
+		// the trailer is only available once the body has been read
+		assert.Equal(t, hex.EncodeToString(body.Sum(nil)), c.Request.Trailer.Get("X-Body-Signature"), "body signature mismatch")
+		c.JSON(http.StatusOK, newSuccessResponse(result))
+	})
+
+	pr, pw := io.Pipe()
+	go func() {
+		records := []string{`{"name":"one"}`, `{"name":"two"}`, `not json`, `{"name":"four"}`}
+		for _, record := range records {
+			pw.Write([]byte(record + "\n"))
+		}
+		pw.Close()
+	}()
+	result, err := client.Tenant.ImportStream(context.TODO(), pr)
+	if err != nil {
+		assert.NoError(t, err, "tenant import error")
+		return
+	}
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, 1, result.Failed)
+	if assert.Len(t, result.Records, 4) {
+		assert.Equal(t, "ten_1", result.Records[0].Id)
+		assert.Equal(t, 3, result.Records[2].Line)
+		assert.NotEmpty(t, result.Records[2].Error, "invalid record should report an error")
+	}
+}
+
+func TestTenantService_ImportStreamError(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/tenants/import", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(http.StatusBadRequest, fmt.Errorf("import disabled")))
+	})
+	_, err := client.Tenant.ImportStream(context.TODO(), strings.NewReader(`{"name":"one"}`+"\n"))
+	assert.Error(t, err, "import should fail")
+	assert.Equal(t, http.StatusBadRequest, statusCode(err))
+}
+
+func TestTenantService_CreateBatch(t *testing.T) {
+	setup()
+	defer teardown()
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	router.POST("/tenants", func(c *gin.Context) {
+		testSignature(c, t)
+		mutex.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mutex.Unlock()
+		defer func() {
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+		var json TenantCreateRequest
+		if err := c.ShouldBindJSON(&json); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		// finish out of order
+		n, _ := strconv.Atoi(strings.TrimPrefix(json.Name, "Test "))
+		time.Sleep(time.Duration(10-n) * 5 * time.Millisecond)
+		if json.Name == "Test 4" {
+			c.JSON(http.StatusBadRequest, newErrorResponse(http.StatusBadRequest, errors.New("name taken")))
+			return
+		}
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Base: Base{Id: fmt.Sprintf("ten_%d", n)}, Name: json.Name}))
+	})
+
+	reqs := make([]*TenantCreateRequest, 10)
+	for i := range reqs {
+		reqs[i] = &TenantCreateRequest{Name: fmt.Sprintf("Test %d", i)}
+	}
+	tenants, errs := client.Tenant.CreateBatch(context.TODO(), reqs, 3)
+	if !assert.Len(t, tenants, len(reqs)) || !assert.Len(t, errs, len(reqs)) {
+		return
+	}
+	for i := range reqs {
+		if i == 4 {
+			assert.Nil(t, tenants[i], "failed create should have no tenant")
+			var apiErr *Error
+			if assert.ErrorAs(t, errs[i], &apiErr) {
+				assert.Equal(t, "name taken", apiErr.Response.ErrorMessage)
+			}
+			continue
+		}
+		if assert.NoError(t, errs[i], "tenant %d create error", i) {
+			assert.Equal(t, reqs[i].Name, tenants[i].Name, "results should be in request order")
+			assert.Equal(t, fmt.Sprintf("ten_%d", i), tenants[i].Id)
+		}
+	}
+	assert.LessOrEqual(t, maxInFlight, 3, "concurrency should be bounded")
+}
+
+func TestTenantService_CreateBatchCanceled(t *testing.T) {
+	setup()
+	defer teardown()
+	calls := 0
+	router.POST("/tenants", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tenants, errs := client.Tenant.CreateBatch(ctx, []*TenantCreateRequest{{Name: "a"}, {Name: "b"}}, 0)
+	assert.Equal(t, []*Tenant{nil, nil}, tenants)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Zero(t, calls, "no create should be started after cancellation")
+}
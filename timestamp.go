@@ -0,0 +1,63 @@
+package zerogate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp is a time encoded on the wire as Unix seconds. The zero
+// Timestamp is encoded as 0 and 0 decodes to the zero Timestamp.
+type Timestamp struct {
+	time.Time
+}
+
+// NewTimestamp creates a Timestamp from Unix seconds.
+func NewTimestamp(sec int64) Timestamp {
+	if sec == 0 {
+		return Timestamp{}
+	}
+	return Timestamp{Time: time.Unix(sec, 0)}
+}
+
+// Unix returns the timestamp as Unix seconds, 0 for the zero Timestamp.
+func (t Timestamp) Unix() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Time.Unix()
+}
+
+// MarshalJSON encodes the timestamp as Unix seconds.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, t.Unix(), 10), nil
+}
+
+// UnmarshalJSON decodes a timestamp from Unix seconds or an RFC 3339 string,
+// null decodes to the zero Timestamp.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("invalid timestamp %s: %w", data, err)
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %s: %w", data, err)
+		}
+		*t = Timestamp{Time: parsed}
+		return nil
+	}
+	sec, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s: %w", data, err)
+	}
+	*t = NewTimestamp(sec)
+	return nil
+}
@@ -0,0 +1,55 @@
+package zerogate
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestamp_JSON(t *testing.T) {
+	tests := []struct {
+		json string
+		unix int64
+	}{
+		{json: "0", unix: 0},
+		{json: "1700000000", unix: 1700000000},
+		// beyond the 32-bit epoch range
+		{json: "4102444800", unix: 4102444800},
+		{json: "253402300799", unix: 253402300799},
+	}
+	for _, test := range tests {
+		var ts Timestamp
+		if !assert.NoError(t, json.Unmarshal([]byte(test.json), &ts), "unmarshal %s", test.json) {
+			continue
+		}
+		assert.Equal(t, test.unix, ts.Unix())
+		assert.Equal(t, test.unix == 0, ts.IsZero(), "only 0 should decode to the zero timestamp")
+		data, err := json.Marshal(ts)
+		assert.NoError(t, err)
+		assert.Equal(t, test.json, string(data), "timestamp should round trip")
+	}
+	ts := NewTimestamp(4102444800)
+	assert.Equal(t, time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC), ts.UTC())
+}
+
+func TestTimestamp_Zero(t *testing.T) {
+	var ts Timestamp
+	data, err := json.Marshal(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", string(data), "zero timestamp should encode as 0")
+
+	ts = NewTimestamp(1700000000)
+	assert.NoError(t, json.Unmarshal([]byte("null"), &ts))
+	assert.True(t, ts.IsZero(), "null should decode to the zero timestamp")
+
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &ts), "invalid timestamp string should fail")
+	assert.Error(t, json.Unmarshal([]byte(`true`), &ts), "non numeric timestamp should fail")
+}
+
+func TestTimestamp_RFC3339(t *testing.T) {
+	var ts Timestamp
+	assert.NoError(t, json.Unmarshal([]byte(`"2023-11-14T22:13:20Z"`), &ts))
+	assert.Equal(t, int64(1700000000), ts.Unix())
+}
@@ -0,0 +1,46 @@
+package zerogate
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zerogate/zerogate-go"
+
+// tracePropagator injects the trace context into outgoing requests.
+var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// startSpan starts a client span for the request and injects its context
+// into the request headers. It returns the request carrying the span context
+// and a function ending the span with the outcome of the request.
+func (c *Client) startSpan(req *http.Request) (*http.Request, func(*APIResponse, error)) {
+	if c.tracer == nil {
+		return req, func(*APIResponse, error) {}
+	}
+	ctx, span := c.tracer.Start(req.Context(), "ZeroGate "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.String()),
+		),
+	)
+	req = req.WithContext(ctx)
+	tracePropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, func(res *APIResponse, err error) {
+		defer span.End()
+		if res != nil {
+			span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+		} else if status := statusCode(err); status != 0 {
+			span.SetAttributes(attribute.Int("http.status_code", status))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
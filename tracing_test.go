@@ -0,0 +1,69 @@
+package zerogate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracerProviderOption(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	setup(WithTracerProvider(tp))
+	defer teardown()
+	router.GET("/traced", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.NotEmpty(t, c.Request.Header.Get("traceparent"), "trace context should be propagated")
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.GET("/failed", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, newErrorsResponse(http.StatusNotFound, "not found"))
+	})
+
+	_, err := client.get(context.Background(), "/traced", nil, nil)
+	assert.NoError(t, err)
+	_, err = client.get(context.Background(), "/failed", nil, nil)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2, "one span should be recorded per request") {
+		return
+	}
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, "GET", spanAttribute(spans[0], "http.method").AsString())
+	assert.Equal(t, server.URL+"/traced", spanAttribute(spans[0], "http.url").AsString())
+	assert.Equal(t, int64(http.StatusOK), spanAttribute(spans[0], "http.status_code").AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, int64(http.StatusNotFound), spanAttribute(spans[1], "http.status_code").AsInt64())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Len(t, spans[1].Events(), 1, "error should be recorded on the span")
+}
+
+func TestTracingDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/untraced", func(c *gin.Context) {
+		assert.Empty(t, c.Request.Header.Get("traceparent"), "no trace context without a provider")
+		c.JSON(http.StatusOK, "ok")
+	})
+	_, err := client.get(context.Background(), "/untraced", nil, nil)
+	assert.NoError(t, err)
+}
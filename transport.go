@@ -0,0 +1,69 @@
+package zerogate
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Middleware wraps the transport used to send requests.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Doer sends HTTP requests, implemented by *http.Client. Set with WithDoer,
+// e.g. to a fake in tests.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// configureTransport composes the shared transport, proxy, TLS configuration
+// and middlewares onto a copy of the HTTP client, leaving the supplied
+// *http.Client untouched.
+func (c *Client) configureTransport() error {
+	if c.sharedTransport == nil && c.proxy == nil && c.tlsClientConfig == nil && len(c.middlewares) == 0 {
+		return nil
+	}
+	// the doer replaces the HTTP client, the transport would silently go unused
+	if c.doer != nil {
+		return fmt.Errorf("shared transport, proxy, TLS and middleware options cannot be combined with a doer")
+	}
+	// a cloned shared transport would no longer share its connection pool
+	if c.sharedTransport != nil && (c.proxy != nil || c.tlsClientConfig != nil) {
+		return fmt.Errorf("proxy and TLS options cannot be combined with a shared transport, configure the shared transport instead")
+	}
+	httpClient := *c.httpClient
+	transport := httpClient.Transport
+	if c.sharedTransport != nil {
+		transport = c.sharedTransport
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if c.proxy != nil || c.tlsClientConfig != nil {
+		t, ok := transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("proxy and TLS options require an *http.Transport, got %T", transport)
+		}
+		// clone so that the transport of the supplied client keeps its settings
+		t = t.Clone()
+		if c.proxy != nil {
+			t.Proxy = http.ProxyURL(c.proxy)
+		}
+		if c.tlsClientConfig != nil {
+			t.TLSClientConfig = c.tlsClientConfig.Clone()
+		}
+		transport = t
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		transport = c.middlewares[i](transport)
+	}
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
@@ -0,0 +1,256 @@
+package zerogate
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareOption(t *testing.T) {
+	var order []string
+	var recorded *http.Request
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	record := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			recorded = req
+			return next.RoundTrip(req)
+		})
+	}
+	setup(WithMiddleware(tag("outer")), WithMiddleware(tag("inner")), WithMiddleware(record))
+	defer teardown()
+	router.GET("/middleware", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.get(context.Background(), "/middleware", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, order, "middlewares should run in order")
+	if assert.NotNil(t, recorded, "request should reach the middleware") {
+		assert.Contains(t, recorded.Header.Get("Authorization"), "Signature=", "request should be signed before the transport")
+	}
+	assert.Nil(t, http.DefaultClient.Transport, "default client should not be modified")
+
+	_, err = New(testApiKey, testApiSecret, WithMiddleware(nil))
+	assert.Error(t, err, "nil middleware should be rejected")
+}
+
+func TestSharedTransportOption(t *testing.T) {
+	setup()
+	defer teardown()
+	secrets := map[string]string{
+		"key_first":  "secret_first",
+		"key_second": "secret_second",
+	}
+	router.GET("/shared", func(c *gin.Context) {
+		params, err := parseAuthorization(c.Request.Header.Get("Authorization"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		testSignatureWithSecret(c, t, secrets[params["APIKey"]])
+		c.JSON(http.StatusOK, params["APIKey"])
+	})
+
+	roundTrips := 0
+	shared := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		roundTrips++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	for key, secret := range secrets {
+		client, err := New(key, secret, WithSharedTransport(shared), BaseURL(server.URL))
+		if !assert.NoError(t, err, "client creation failed") {
+			return
+		}
+		res, err := client.doRequestString(context.Background(), http.MethodGet, "/shared", nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, key, res, "request should be signed with the client's own key")
+	}
+	assert.Equal(t, len(secrets), roundTrips, "requests should go through the shared transport")
+
+	_, err := New(testApiKey, testApiSecret, WithSharedTransport(nil))
+	assert.Error(t, err, "nil transport should be rejected")
+}
+
+func TestProxyOption(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Method+" "+r.URL.String())
+		assert.NotEmpty(t, r.Header.Get("Authorization"), "proxied request should be signed")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"ok"`))
+	}))
+	defer proxy.Close()
+
+	transport := &http.Transport{MaxIdleConnsPerHost: 7}
+	client, err := New(testApiKey, testApiSecret,
+		HTTPClient(&http.Client{Transport: transport}),
+		BaseURL("http://api.zerogate.test/public/v1"),
+		WithProxy(proxy.URL))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	_, err = client.get(context.Background(), "/tenants", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET http://api.zerogate.test/public/v1/tenants"}, proxied)
+
+	configured, ok := client.httpClient.Transport.(*http.Transport)
+	if assert.True(t, ok, "transport should stay an *http.Transport") {
+		assert.Equal(t, 7, configured.MaxIdleConnsPerHost, "supplied transport settings should be kept")
+	}
+	assert.Nil(t, transport.Proxy, "supplied transport should not be modified")
+
+	_, err = New(testApiKey, testApiSecret, WithProxy("://bad"))
+	assert.Error(t, err, "invalid proxy url should be rejected")
+	_, err = New(testApiKey, testApiSecret, WithProxy("ftp://proxy.internal"))
+	assert.Error(t, err, "unsupported proxy scheme should be rejected")
+	custom := RoundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	_, err = New(testApiKey, testApiSecret, HTTPClient(&http.Client{Transport: custom}), WithProxy(proxy.URL))
+	assert.Error(t, err, "proxy should require an *http.Transport")
+}
+
+func TestTLSConfigOption(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"ok"`))
+	}))
+	defer tlsServer.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(tlsServer.Certificate())
+
+	client, err := New(testApiKey, testApiSecret, BaseURL(tlsServer.URL), WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	_, err = client.get(context.Background(), "/tenants", nil, nil)
+	assert.NoError(t, err, "custom root CA should be trusted")
+	assert.NotSame(t, http.DefaultTransport, client.httpClient.Transport, "default transport should be cloned")
+
+	client, err = New(testApiKey, testApiSecret, BaseURL(tlsServer.URL))
+	if err != nil {
+		assert.NoError(t, err, "client creation failed")
+		return
+	}
+	_, err = client.get(context.Background(), "/tenants", nil, nil)
+	assert.Error(t, err, "server certificate should not be trusted without the custom root CA")
+
+	_, err = New(testApiKey, testApiSecret, WithTLSConfig(nil))
+	assert.Error(t, err, "nil TLS config should be rejected")
+	custom := RoundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	_, err = New(testApiKey, testApiSecret, HTTPClient(&http.Client{Transport: custom}), WithTLSConfig(&tls.Config{}))
+	assert.ErrorContains(t, err, "*http.Transport", "TLS config should require an *http.Transport")
+}
+
+func TestPanicRecoveryOption(t *testing.T) {
+	panicking := &http.Client{Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		panic("transport exploded")
+	})}
+	recovering, err := New(testApiKey, testApiSecret, HTTPClient(panicking), WithPanicRecovery())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = recovering.Tenant.Get(context.Background(), "ten_1")
+	if assert.Error(t, err, "panic should be returned as an error") {
+		assert.Contains(t, err.Error(), "transport exploded")
+		assert.Contains(t, err.Error(), "TestPanicRecoveryOption", "error should carry the stack")
+	}
+
+	setup(WithPanicRecovery(), WithResponseHook(func(*http.Response, time.Duration) {
+		panic("hook exploded")
+	}))
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+	_, err = client.Tenant.Get(context.Background(), "ten_1")
+	assert.ErrorContains(t, err, "hook exploded")
+
+	propagating, err := New(testApiKey, testApiSecret, HTTPClient(panicking))
+	if assert.NoError(t, err) {
+		assert.Panics(t, func() { propagating.Tenant.Get(context.Background(), "ten_1") }, "panics should propagate by default")
+	}
+}
+
+type fakeDoer struct {
+	requests []*http.Request
+}
+
+func (d *fakeDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"success":true,"data":{"id":"ten_1","name":"Test"}}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestDoerOption(t *testing.T) {
+	doer := &fakeDoer{}
+	client, err := New(testApiKey, testApiSecret, WithDoer(doer))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tenant, err := client.Tenant.Get(context.Background(), "ten_1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ten_1", tenant.Id)
+		assert.Equal(t, "Test", tenant.Name)
+	}
+	if assert.Len(t, doer.requests, 1, "request should be sent with the doer") {
+		req := doer.requests[0]
+		assert.Equal(t, "/public/v1/tenants/ten_1", req.URL.Path)
+		assert.Contains(t, req.Header.Get("Authorization"), "Signature=", "request should be signed")
+	}
+
+	clone, err := client.Clone()
+	if assert.NoError(t, err) {
+		_, err = clone.Tenant.Get(context.Background(), "ten_1")
+		assert.NoError(t, err)
+		assert.Len(t, doer.requests, 2, "clone should keep the doer")
+	}
+
+	_, err = New(testApiKey, testApiSecret, WithDoer(nil))
+	assert.Error(t, err, "nil doer should be rejected")
+
+	for name, option := range map[string]Option{
+		"shared transport": WithSharedTransport(http.DefaultTransport),
+		"proxy":            WithProxy("http://proxy.internal:3128"),
+		"TLS config":       WithTLSConfig(&tls.Config{}),
+		"middleware":       WithMiddleware(func(rt http.RoundTripper) http.RoundTripper { return rt }),
+	} {
+		_, err = New(testApiKey, testApiSecret, WithDoer(doer), option)
+		assert.Error(t, err, "%s should not be combined with a doer", name)
+	}
+}
+
+func TestSharedTransportExclusive(t *testing.T) {
+	shared := http.DefaultTransport.(*http.Transport).Clone()
+	_, err := New(testApiKey, testApiSecret, WithSharedTransport(shared), WithProxy("http://proxy.internal:3128"))
+	assert.Error(t, err, "proxy should not be combined with a shared transport")
+	_, err = New(testApiKey, testApiSecret, WithSharedTransport(shared), WithTLSConfig(&tls.Config{}))
+	assert.Error(t, err, "TLS config should not be combined with a shared transport")
+
+	client, err := New(testApiKey, testApiSecret, WithSharedTransport(shared))
+	if assert.NoError(t, err) {
+		assert.Same(t, shared, client.httpClient.Transport, "shared transport should be used as is")
+	}
+}
@@ -0,0 +1,47 @@
+package zerogate
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+)
+
+// VerifyWebhook verifies the signature header of a webhook delivered by
+// ZeroGate, "Signature=<hex>, Nonce=<nonce>" optionally followed by
+// ", Algorithm=<algorithm>". The signature is the HMAC of the nonce followed
+// by the raw body, HMAC-SHA512 unless an algorithm is given. A mismatch
+// returns ErrWebhookSignatureMismatch.
+func VerifyWebhook(secret string, body []byte, header string) error {
+	params, err := parseAuthorization(header)
+	if err != nil {
+		return fmt.Errorf("invalid webhook signature header: %w", err)
+	}
+	signature, ok := params["Signature"]
+	if !ok || signature == "" {
+		return fmt.Errorf("webhook signature header has no signature")
+	}
+	nonce, ok := params["Nonce"]
+	if !ok || nonce == "" {
+		return fmt.Errorf("webhook signature header has no nonce")
+	}
+	algorithm := SignatureHMACSHA512
+	if value, ok := params["Algorithm"]; ok {
+		algorithm = SignatureAlgorithm(value)
+	}
+	newHash := algorithm.hash()
+	if newHash == nil {
+		return fmt.Errorf("unsupported webhook signature algorithm %q", algorithm)
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid webhook signature: %w", err)
+	}
+
+	h := hmac.New(newHash, []byte(secret))
+	h.Write([]byte(nonce))
+	h.Write(body)
+	if !hmac.Equal(h.Sum(nil), actual) {
+		return ErrWebhookSignatureMismatch
+	}
+	return nil
+}
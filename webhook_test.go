@@ -0,0 +1,51 @@
+package zerogate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func webhookSignature(newHash func() hash.Hash, secret, nonce string, body []byte) string {
+	h := hmac.New(newHash, []byte(secret))
+	h.Write([]byte(nonce))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"tenant.created","data":{"id":"ten_ea87af463d9fc38203690805c1c1fa33"}}`)
+	nonce := "1700000000.9f86d081884c7d65"
+	header := "Signature=" + webhookSignature(sha512.New, testApiSecret, nonce, body) + ", Nonce=" + nonce
+	assert.NoError(t, VerifyWebhook(testApiSecret, body, header), "valid signature should verify")
+
+	sha256Header := "Signature=" + webhookSignature(sha256.New, testApiSecret, nonce, body) + ", Nonce=" + nonce + ", Algorithm=HMAC-SHA256"
+	assert.NoError(t, VerifyWebhook(testApiSecret, body, sha256Header), "tagged algorithm should be honored")
+
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-3] = 'X'
+	assert.ErrorIs(t, VerifyWebhook(testApiSecret, tampered, header), ErrWebhookSignatureMismatch, "tampered body should fail")
+	assert.ErrorIs(t, VerifyWebhook("wrong secret", body, header), ErrWebhookSignatureMismatch, "wrong secret should fail")
+	replayed := "Signature=" + webhookSignature(sha512.New, testApiSecret, nonce, body) + ", Nonce=1700000001.9f86d081884c7d65"
+	assert.ErrorIs(t, VerifyWebhook(testApiSecret, body, replayed), ErrWebhookSignatureMismatch, "altered nonce should fail")
+
+	malformed := []string{
+		"",
+		"garbage",
+		"Nonce=" + nonce,
+		"Signature=abc",
+		"Signature=not-hex, Nonce=" + nonce,
+		"Signature=abcd, Nonce=" + nonce + ", Algorithm=HMAC-MD5",
+	}
+	for _, header := range malformed {
+		err := VerifyWebhook(testApiSecret, body, header)
+		if assert.Error(t, err, "header %q should be rejected", header) {
+			assert.NotErrorIs(t, err, ErrWebhookSignatureMismatch, "malformed header %q should not be reported as a mismatch", header)
+		}
+	}
+}
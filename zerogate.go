@@ -2,21 +2,31 @@ package zerogate
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/hmac"
-	"crypto/sha512"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"regexp"
+	"os"
+	"path"
+	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 )
 
 type service struct {
@@ -25,30 +35,81 @@ type service struct {
 
 // Client holds the configuration for the current API client.
 type Client struct {
-	mutex      sync.RWMutex
-	apiKey     string
-	apiSecret  string
-	baseUrl    string
-	debug      bool
-	userAgent  string
-	headers    http.Header
-	httpClient *http.Client
-	logger     *log.Logger
+	mutex       sync.RWMutex
+	apiKey      string
+	apiSecret   string
+	bearerToken string
+	baseUrl     string
+	debug       bool
+	userAgent   string
+	headers     http.Header
+	httpClient  *http.Client
+	doer        Doer
+	logger      *log.Logger
+
+	debugWriter      io.Writer
+	debugBodyLimit   int
+	baseHTTPClient   *http.Client
+	timeout          time.Duration
+	endpointTimeouts map[string]time.Duration
+	retry            RetryPolicy
+	retryPredicate   func(*APIResponse, error) bool
+	failoverURLs     []string
+	limiter          *rate.Limiter
+	verifyDigest     bool
+	prefer           string
+	slogger          *slog.Logger
+	middlewares      []Middleware
+	sharedTransport  http.RoundTripper
+	proxy            *url.URL
+	tlsClientConfig  *tls.Config
+	tracer           trace.Tracer
+	idempotentDelete bool
+	acceptFallback   bool
+	strictDecoding   bool
+	metrics          *metrics
+	observer         func(methodPath string, status int, dur time.Duration, err error)
+	compressRequests bool
+	panicRecovery    bool
+	maxRequestSize   int64
+	maxResponseSize  int64
+	cache            *responseCache
+	bodyTransformer  func([]byte) ([]byte, error)
+	requestHooks     []func(*http.Request)
+	responseHooks    []func(*http.Response, time.Duration)
+	bufferLimit      int64
+	bufferSem        *semaphore.Weighted
+	nonceGenerator   NonceGenerator
+	nonceResolution  time.Duration
+	signature        SignatureAlgorithm
+	clock            func() time.Time
+
+	clockSkewTolerance time.Duration
 
 	common service
 
-	Tenant *TenantService
+	Tenant       *TenantService
+	Organization *OrganizationService
 }
 
+// silentLogger is the default logger, discarding all output.
+var silentLogger = log.New(io.Discard, "", log.LstdFlags)
+
 // newClient provides shared logic for New.
 func newClient(opts ...Option) (*Client, error) {
-	silentLogger := log.New(io.Discard, "", log.LstdFlags)
-
 	client := &Client{
 		baseUrl:   baseUrl,
 		userAgent: userAgent,
 		headers:   make(http.Header),
 		logger:    silentLogger,
+
+		nonceGenerator:  randomNonceGenerator{},
+		nonceResolution: time.Second,
+		signature:       SignatureHMACSHA512,
+		clock:           time.Now,
+		debugBodyLimit:  defaultDebugBodyLimit,
+
+		clockSkewTolerance: defaultClockSkewTolerance,
 	}
 	client.common.client = client
 
@@ -57,21 +118,38 @@ func newClient(opts ...Option) (*Client, error) {
 		return nil, fmt.Errorf("options parsing failed: %w", err)
 	}
 
-	if client.httpClient == nil {
-		client.httpClient = http.DefaultClient
+	err = client.finish()
+	if err != nil {
+		return nil, err
 	}
-
-	client.Tenant = (*TenantService)(&client.common)
-
 	return client, nil
 }
 
-// New creates a new ZeroGate API client.
-func New(key, secret string, opts ...Option) (*Client, error) {
-	if key == "" || secret == "" {
-		return nil, errors.New(errEmptyCredentials)
+// finish completes the client configuration once the options are applied.
+func (c *Client) finish() error {
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	// the transport is composed onto a copy, keep the supplied client so that
+	// clones can compose it again with their own options
+	c.baseHTTPClient = c.httpClient
+	err := c.configureTransport()
+	if err != nil {
+		return fmt.Errorf("transport configuration failed: %w", err)
+	}
+	// debug output goes to the standard logger unless a logger was supplied
+	if c.debug && c.logger == silentLogger {
+		c.logger = log.Default()
 	}
 
+	c.Tenant = (*TenantService)(&c.common)
+	c.Organization = (*OrganizationService)(&c.common)
+	return nil
+}
+
+// New creates a new ZeroGate API client. With WithBearerToken the key and
+// secret must be empty.
+func New(key, secret string, opts ...Option) (*Client, error) {
 	api, err := newClient(opts...)
 	if err != nil {
 		return nil, err
@@ -79,10 +157,65 @@ func New(key, secret string, opts ...Option) (*Client, error) {
 
 	api.apiKey = key
 	api.apiSecret = secret
+	err = api.checkCredentials()
+	if err != nil {
+		return nil, err
+	}
 
 	return api, nil
 }
 
+// checkCredentials checks that the client has either an API key and secret
+// or a bearer token.
+func (c *Client) checkCredentials() error {
+	if c.bearerToken != "" {
+		if c.apiKey != "" || c.apiSecret != "" {
+			return errors.New(errConflictingCredentials)
+		}
+		return nil
+	}
+	if c.apiKey == "" || c.apiSecret == "" {
+		return errors.New(errEmptyCredentials)
+	}
+	return nil
+}
+
+// NewFromEnv creates a new ZeroGate API client with the credentials read from
+// the ZEROGATE_API_KEY and ZEROGATE_API_SECRET environment variables, and the
+// base URL from ZEROGATE_BASE_URL when set. A BaseURL option overrides the
+// environment.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	key := os.Getenv(envAPIKey)
+	if key == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", envAPIKey)
+	}
+	secret := os.Getenv(envAPISecret)
+	if secret == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", envAPISecret)
+	}
+	if baseURL := os.Getenv(envBaseURL); baseURL != "" {
+		opts = append([]Option{BaseURL(baseURL)}, opts...)
+	}
+	return New(key, secret, opts...)
+}
+
+// SetCredentials replaces the API key and secret, e.g. to rotate keys without
+// recreating the client. Requests already being signed keep the credentials
+// they started with.
+func (c *Client) SetCredentials(key, secret string) error {
+	if key == "" || secret == "" {
+		return errors.New(errEmptyCredentials)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.bearerToken != "" {
+		return errors.New(errConflictingCredentials)
+	}
+	c.apiKey = key
+	c.apiSecret = secret
+	return nil
+}
+
 func (c *Client) getClient() *http.Client {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -91,136 +224,525 @@ func (c *Client) getClient() *http.Client {
 	return &clientCopy
 }
 
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, query map[string][]string, body interface{}, headers http.Header) (*APIResponse, error) {
-	var err error
-	var resp *http.Response
-	var respBody []byte
+// getDoer returns the Doer set with WithDoer, or else a copy of the HTTP
+// client.
+func (c *Client) getDoer() Doer {
+	if c.doer != nil {
+		return c.doer
+	}
+	return c.getClient()
+}
+
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, query map[string][]string, body interface{}, headers http.Header, opts ...RequestOption) (res *APIResponse, err error) {
+	if c.metrics != nil {
+		done := c.metrics.start(method)
+		defer func() { done(res, err) }()
+	}
+	if c.observer != nil {
+		start := time.Now()
+		defer func() { c.observer(method+" "+endpoint, responseStatus(res, err), time.Since(start), err) }()
+	}
+
+	options := newRequestOptions(opts)
+	ctx, query, headers = options.apply(ctx, query, headers)
 
 	c.mutex.RLock()
-	apiKey := c.apiKey
-	apiSecret := c.apiSecret
-	baseUrl := c.baseUrl
-	debug := c.debug
-	userAgent := c.userAgent
-	apiHeaders := c.headers
+	retry := c.retry
 	c.mutex.RUnlock()
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		retry = policy
+	}
 
-	var reqBody io.Reader
-	if body != nil && (method == http.MethodPost || method == http.MethodPut) {
-		if r, ok := body.(io.Reader); ok {
-			reqBody = r
-		} else if bodyBytes, ok := body.([]byte); ok {
-			reqBody = bytes.NewReader(bodyBytes)
-		} else {
-			var jsonBody []byte
-			jsonBody, err = json.Marshal(body)
-			if err != nil {
-				return nil, fmt.Errorf("error marshalling body to JSON: %w", err)
+	// retries of the request share its request ID
+	if RequestID(ctx) == "" {
+		ctx = WithCallRequestID(ctx, newRequestID())
+	}
+
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	} else if timeout, ok := c.endpointTimeout(endpoint); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	} else if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	bodyBytes, contentType, err := requestBody(method, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" && headers.Get("Content-Type") == "" {
+		headers = headers.Clone()
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set("Content-Type", contentType)
+	}
+	if c.bodyTransformer != nil && bodyBytes != nil {
+		bodyBytes, err = c.bodyTransformer(bodyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error transforming body: %w", err)
+		}
+	}
+	if c.maxRequestSize > 0 && int64(len(bodyBytes)) > c.maxRequestSize {
+		c.logger.Printf("rejected %s %s: body of %d bytes exceeds the limit of %d bytes", method, endpoint, len(bodyBytes), c.maxRequestSize)
+		return nil, fmt.Errorf("%w: body of %d bytes exceeds %d bytes", ErrRequestTooLarge, len(bodyBytes), c.maxRequestSize)
+	}
+	if c.bufferSem != nil && len(bodyBytes) > 0 {
+		n := min(int64(len(bodyBytes)), c.bufferLimit)
+		err = c.bufferSem.Acquire(ctx, n)
+		if err != nil {
+			return nil, fmt.Errorf("ZeroGate buffer limit wait failed: %w", err)
+		}
+		defer c.bufferSem.Release(n)
+	}
+	if c.compressRequests && len(bodyBytes) > compressionThreshold {
+		bodyBytes, err = gzipBody(bodyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error compressing body: %w", err)
+		}
+		headers = headers.Clone()
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set("Content-Encoding", "gzip")
+	}
+
+	headers, idempotent := ensureIdempotencyKey(ctx, method, headers, retry)
+	baseURLs := c.baseURLs()
+	current := 0
+	for attempt := 0; ; attempt++ {
+		attemptCtx := ctx
+		if len(baseURLs) > 1 {
+			attemptCtx = context.WithValue(ctx, baseURLKey{}, baseURLs[current])
+		}
+		res, err := c.sendNegotiated(attemptCtx, method, endpoint, query, bodyBytes, headers)
+		if attempt >= retry.MaxRetries || !isRetryable(method, idempotent, err) && !c.retryWanted(method, idempotent, res, err) {
+			if method == http.MethodDelete && c.idempotentDelete && IsNotFound(err) {
+				return &APIResponse{StatusCode: http.StatusNotFound, Status: http.StatusText(http.StatusNotFound)}, nil
 			}
-			reqBody = bytes.NewReader(jsonBody)
+			return res, err
+		}
+		if shouldFailover(err) {
+			current = (current + 1) % len(baseURLs)
+		}
+		if c.metrics != nil {
+			c.metrics.retries.WithLabelValues(method).Inc()
+		}
+		delay := retry.delay(attempt)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		err = sleepContext(ctx, delay)
+		if err != nil {
+			return nil, fmt.Errorf("ZeroGate request failed: %w", err)
 		}
-	} else if method == http.MethodPost || method == http.MethodPut {
-		reqBody = bytes.NewReader([]byte("{}"))
 	}
-	var bodyBytes []byte
+}
 
-	if method == http.MethodPost || method == http.MethodPut {
-		bodyBytes, err = io.ReadAll(reqBody)
+// requestBody returns the bytes to send and sign for the request body, along
+// with its Content-Type when it is not JSON. url.Values are form encoded, a
+// MultipartBody multipart encoded and other values JSON encoded. POST, PUT
+// and PATCH requests always carry a body, sent as an empty JSON object when
+// none is given, other methods only when one is given.
+func requestBody(method string, body interface{}) ([]byte, string, error) {
+	if body == nil {
+		switch method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			return []byte("{}"), "", nil
+		}
+		return nil, "", nil
+	}
+	switch b := body.(type) {
+	case io.Reader:
+		bodyBytes, err := io.ReadAll(b)
 		if err != nil {
-			return nil, fmt.Errorf("error reading body: %w", err)
+			return nil, "", fmt.Errorf("error reading body: %w", err)
 		}
-		reqBody = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		return bodyBytes, "", nil
+	case []byte:
+		return b, "", nil
+	case url.Values:
+		return []byte(b.Encode()), "application/x-www-form-urlencoded", nil
+	case *MultipartBody:
+		return b.encode()
+	case MultipartBody:
+		return b.encode()
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshalling body to JSON: %w", err)
 	}
+	return jsonBody, "", nil
+}
 
-	// Get the current datetime in ISO 8601 format
-	now := time.Now().Unix()
+// gzipBody compresses the request body, it is signed in compressed form so
+// that the signature matches the bytes on the wire.
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, baseUrl+endpoint, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("ZeroGate request creation failed: %w", err)
+// sendNegotiated sends the request, and when the accept fallback is enabled
+// resends it once with "Accept: */*" after a 406 Not Acceptable response.
+func (c *Client) sendNegotiated(ctx context.Context, method, endpoint string, query map[string][]string, bodyBytes []byte, headers http.Header) (*APIResponse, error) {
+	res, err := c.send(ctx, method, endpoint, query, bodyBytes, headers)
+	var apiErr *Error
+	if !c.acceptFallback || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotAcceptable {
+		return res, err
 	}
-	// Convert the map to a URL query string
+	fallbackHeaders := headers.Clone()
+	if fallbackHeaders == nil {
+		fallbackHeaders = make(http.Header)
+	}
+	fallbackHeaders.Set("Accept", "*/*")
+	return c.send(ctx, method, endpoint, query, bodyBytes, fallbackHeaders)
+}
+
+// BaseURL returns the base URL API calls are sent to.
+func (c *Client) BaseURL() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.baseUrl
+}
+
+// buildURL returns the full URL a call to the endpoint with the query is sent
+// to.
+func (c *Client) buildURL(endpoint string, query map[string][]string) string {
+	return requestURL(c.BaseURL(), endpoint, query)
+}
+
+// requestURL joins the base URL and endpoint and appends the encoded query.
+// The query is left out when there are no parameters so the URL has no
+// trailing "?", it is not part of the signed message.
+func requestURL(baseUrl, endpoint string, query map[string][]string) string {
 	values := url.Values{}
 	for k, v := range query {
 		for _, vv := range v {
 			values.Add(k, vv)
 		}
 	}
-	queryString := values.Encode()
-	req.URL.RawQuery = queryString
+	if len(values) == 0 {
+		return baseUrl + endpoint
+	}
+	return baseUrl + endpoint + "?" + values.Encode()
+}
+
+// signedRequest is a request built by newSignedRequest, along with the client
+// configuration it was built with.
+type signedRequest struct {
+	req     *http.Request
+	nonce   string
+	baseURL string
+	debug   bool
 
-	combinedHeaders := make(http.Header)
-	for k, v := range apiHeaders {
-		combinedHeaders[k] = v
+	apiKey      string
+	apiSecret   string
+	bearerToken string
+}
+
+// newSignedRequest waits for the rate limiter and builds the request of a
+// single attempt: sent to the base URL of the attempt, with the client,
+// context and per-request headers, authorized and with the default headers
+// set.
+func (c *Client) newSignedRequest(ctx context.Context, method, endpoint string, query map[string][]string, bodyBytes []byte, headers http.Header) (*signedRequest, error) {
+	c.mutex.RLock()
+	sr := &signedRequest{
+		baseURL:     c.baseUrl,
+		debug:       c.debug,
+		apiKey:      c.apiKey,
+		apiSecret:   c.apiSecret,
+		bearerToken: c.bearerToken,
 	}
-	for k, v := range headers {
-		combinedHeaders[k] = v
+	userAgent := c.userAgent
+	apiHeaders := c.headers
+	c.mutex.RUnlock()
+	if u, ok := ctx.Value(baseURLKey{}).(string); ok {
+		sr.baseURL = u
 	}
-	req.Header = combinedHeaders
 
-	// Combine the method, endpoint, and datetime into the message to sign
-	message := req.Method + req.URL.Path + fmt.Sprint(now)
+	if c.limiter != nil {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ZeroGate rate limiter wait failed: %w", err)
+		}
+	}
 
-	// Create an HMAC-SHA512 hash using the API secret as the key
-	h := hmac.New(sha512.New, []byte(apiSecret))
-	h.Write([]byte(message))
-	if method == http.MethodPost || method == http.MethodPut {
-		h.Write(bodyBytes)
+	var reqBody io.Reader
+	if bodyBytes != nil {
+		reqBody = bytes.NewReader(bodyBytes)
 	}
-	signature := hex.EncodeToString(h.Sum(nil))
+	req, err := http.NewRequestWithContext(ctx, method, requestURL(sr.baseURL, endpoint, query), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("ZeroGate request creation failed: %w", err)
+	}
+	req.Header = combineHeaders(apiHeaders, contextHeaders(ctx), headers)
+	sr.nonce = c.authorize(req, bodyBytes, sr.apiKey, sr.apiSecret, sr.bearerToken)
+	c.setDefaultHeaders(ctx, req, userAgent)
+	sr.req = req
+	return sr, nil
+}
 
-	req.Header.Set("Authorization", fmt.Sprintf("APIKey=%s, Signature=%s, Nonce=%d", apiKey, signature, now))
-	if userAgent != "" {
-		req.Header.Set("User-Agent", userAgent)
+// send signs and sends a single attempt of the request.
+func (c *Client) send(ctx context.Context, method, endpoint string, query map[string][]string, bodyBytes []byte, headers http.Header) (*APIResponse, error) {
+	sr, err := c.newSignedRequest(ctx, method, endpoint, query, bodyBytes, headers)
+	if err != nil {
+		return nil, err
 	}
-	if req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+	req, nonce, debug := sr.req, sr.nonce, sr.debug
+
+	var cached *cacheEntry
+	var key string
+	if c.cache != nil && method == http.MethodGet {
+		key = cacheKey(method, req.URL)
+		var ok bool
+		cached, ok = c.cache.get(key)
+		if ok && req.Header.Get("If-None-Match") == "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
 	}
 
+	req, endSpan := c.startSpan(req)
+
 	if debug {
-		dump, err := httputil.DumpRequestOut(req, true)
+		err = c.dumpRequest(req, true, sr.apiKey, sr.apiSecret, sr.bearerToken)
 		if err != nil {
 			return nil, err
 		}
-		// strip out any sensitive information from the request payload.
-		sensitiveKeys := []string{apiKey, apiSecret}
-		for _, key := range sensitiveKeys {
-			if key != "" {
-				valueRegex := regexp.MustCompile(fmt.Sprintf("(?m)%s", key))
-				dump = valueRegex.ReplaceAll(dump, []byte("[**************]"))
+	}
+	start := time.Now()
+	res, err := c.execute(req, debug)
+	if err == nil && c.cache != nil {
+		if res.StatusCode == http.StatusNotModified && cached != nil {
+			// a 304 validating another representation must not be answered
+			// with the cached one
+			if etag := res.Headers.Get("ETag"); etag == "" || etagsMatch(etag, cached.etag) {
+				requestID := res.RequestID
+				res = cached.cachedResponse()
+				res.RequestID = requestID
+			} else {
+				c.cache.remove(key)
 			}
+		} else if cacheable(method, res) {
+			c.cache.add(key, res.Headers.Get("ETag"), res)
+		}
+	}
+	var apiErr *Error
+	if debug && nonce != "" && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		apiErr.Signing = &SigningDebug{
+			Nonce:   nonce,
+			Message: string(signedMessage(req.Method, req.URL.Path, nonce, bodyBytes)),
 		}
-		log.Printf("\n%s", string(dump))
 	}
-	client := c.getClient()
-	resp, err = client.Do(req)
+	if res != nil {
+		res.BaseURL = sr.baseURL
+	}
+	endSpan(res, err)
+	c.logRequest(ctx, req, nonce, time.Since(start), res, err)
+	return res, err
+}
+
+// sendStream signs and sends a single attempt of a request whose body is
+// streamed from r with chunked transfer encoding. As the body is not known
+// up front, the Authorization header signs the request like one without a
+// body, and the body signature is sent in the X-Body-Signature trailer once
+// the stream has been read. In bearer mode no body signature is sent.
+func (c *Client) sendStream(ctx context.Context, method, endpoint string, r io.Reader, headers http.Header) (*APIResponse, error) {
+	sr, err := c.newSignedRequest(ctx, method, endpoint, nil, nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	req, nonce, debug := sr.req, sr.nonce, sr.debug
+
+	if sr.bearerToken != "" {
+		req.Body = io.NopCloser(r)
+	} else {
+		h := hmac.New(c.signature.hash(), []byte(sr.apiSecret))
+		h.Write([]byte(nonce))
+		req.Trailer = http.Header{"X-Body-Signature": nil}
+		req.Body = io.NopCloser(&signingReader{r: r, h: h, trailer: req.Trailer})
+	}
+	// an unknown length makes the transport use chunked transfer encoding
+	req.ContentLength = -1
+
+	req, endSpan := c.startSpan(req)
+
+	if debug {
+		// the body can only be read once, so it is left out of the dump
+		err = c.dumpRequest(req, false, sr.apiKey, sr.apiSecret, sr.bearerToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	res, err := c.execute(req, debug)
+	endSpan(res, err)
+	c.logRequest(ctx, req, nonce, time.Since(start), res, err)
+	return res, err
+}
+
+// signingReader feeds everything read from r into the HMAC and sets the body
+// signature trailer when r is exhausted.
+type signingReader struct {
+	r       io.Reader
+	h       hash.Hash
+	trailer http.Header
+}
+
+func (s *signingReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.h.Write(p[:n])
+	if err == io.EOF {
+		s.trailer.Set("X-Body-Signature", hex.EncodeToString(s.h.Sum(nil)))
+	}
+	return n, err
+}
+
+// combineHeaders merges headers in increasing order of precedence, e.g. the
+// client default headers and then the per-request headers.
+func combineHeaders(headers ...http.Header) http.Header {
+	combined := make(http.Header)
+	for _, h := range headers {
+		for k, v := range h {
+			combined[k] = v
+		}
+	}
+	return combined
+}
+
+// setDefaultHeaders sets the User-Agent and any of the Content-Type, Accept,
+// Prefer and X-Request-ID headers the request does not set itself.
+func (c *Client) setDefaultHeaders(ctx context.Context, req *http.Request, userAgent string) {
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	if prefer := c.preferHeader(ctx); prefer != "" && req.Header.Get("Prefer") == "" {
+		req.Header.Set("Prefer", prefer)
+	}
+	if req.Header.Get(requestIDHeader) == "" {
+		id := RequestID(ctx)
+		if id == "" {
+			id = newRequestID()
+		}
+		req.Header.Set(requestIDHeader, id)
+	}
+}
+
+// dumpRequest logs the outgoing request with the credentials stripped out.
+func (c *Client) dumpRequest(req *http.Request, body bool, apiKey, apiSecret, bearerToken string) error {
+	dump, err := httputil.DumpRequestOut(req, body)
+	if err != nil {
+		return err
+	}
+	// strip out any sensitive information from the request payload.
+	sensitiveKeys := []string{apiKey, apiSecret, bearerToken}
+	for _, key := range sensitiveKeys {
+		if key != "" {
+			dump = bytes.ReplaceAll(dump, []byte(key), []byte("[**************]"))
+		}
+	}
+	c.writeDump(dump)
+	return nil
+}
+
+// writeDump writes a debug dump to the debug writer, or the logger when none
+// is set. Bodies beyond the debug body limit are truncated.
+func (c *Client) writeDump(dump []byte) {
+	dump = truncateDump(dump, c.debugBodyLimit)
+	if c.debugWriter != nil {
+		fmt.Fprintf(c.debugWriter, "\n%s\n", dump)
+		return
+	}
+	c.logger.Printf("\n%s", string(dump))
+}
+
+// truncateDump truncates the body of an HTTP dump to limit bytes, marking
+// how many bytes were left out.
+func truncateDump(dump []byte, limit int) []byte {
+	i := bytes.Index(dump, []byte("\r\n\r\n"))
+	if i < 0 {
+		return dump
+	}
+	bodyStart := i + 4
+	if len(dump)-bodyStart <= limit {
+		return dump
+	}
+	truncated := len(dump) - bodyStart - limit
+	out := append([]byte(nil), dump[:bodyStart+limit]...)
+	return append(out, fmt.Sprintf("...[truncated %d bytes]", truncated)...)
+}
+
+// execute sends the signed request and reads the response.
+func (c *Client) execute(req *http.Request, debug bool) (*APIResponse, error) {
+	if c.verifyDigest && req.Header.Get("Accept-Encoding") == "" {
+		// keep the transport from transparently decompressing the body, the
+		// digest covers the content coded bytes
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("ZeroGate request failed: %w", err)
 	}
+	verified := false
+	if c.verifyDigest && gzipEncoded(resp) {
+		raw, err := io.ReadAll(c.limitBody(resp.Body))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("response read failed: %w", err)
+		}
+		err = verifyDigest(resp.Header, raw)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+		verified = true
+	}
+	resp, err = decompressResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = c.limitBody(resp.Body)
 	defer resp.Body.Close()
 	if debug {
 		dump, err := httputil.DumpResponse(resp, true)
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("\n%s", string(dump))
+		c.writeDump(dump)
 	}
-	respBody, err = io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("response read failed: %w", err)
 	}
-
-	if resp.StatusCode >= http.StatusBadRequest {
-		var r ErrorResponse
-		err = json.Unmarshal(respBody, &r)
+	// a body the transport decompressed can no longer be verified
+	if c.verifyDigest && !verified && !resp.Uncompressed {
+		err = verifyDigest(resp.Header, respBody)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
-		}
-		err = &Error{
-			StatusCode: resp.StatusCode,
-			Response:   r,
+			return nil, err
 		}
-		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, c.responseError(req, resp, respBody)
 	}
 
 	return &APIResponse{
@@ -228,21 +750,388 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, query m
 		StatusCode: resp.StatusCode,
 		Status:     resp.Status,
 		Headers:    resp.Header,
+		RequestID:  responseRequestID(req, resp),
+
+		NotModified: resp.StatusCode == http.StatusNotModified,
 	}, nil
 }
 
-func (c *Client) get(ctx context.Context, endpoint string, query map[string][]string, headers http.Header) (*APIResponse, error) {
-	return c.doRequest(ctx, http.MethodGet, endpoint, query, nil, headers)
+// roundTrip runs the hooks and sends the signed request. A gzip response body
+// is decompressed, the caller must close the body.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ZeroGate request failed: %w", err)
+	}
+	return decompressResponse(resp)
+}
+
+// gzipEncoded reports whether the response body is gzip compressed.
+func gzipEncoded(resp *http.Response) bool {
+	return strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip")
+}
+
+// decompressResponse decompresses a gzip response body. The transport only
+// decompresses responses to requests it added Accept-Encoding to itself, so
+// handle a gzip body when the header was set by the caller. Other bodies are
+// read as is.
+func decompressResponse(resp *http.Response) (*http.Response, error) {
+	if !gzipEncoded(resp) {
+		return resp, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	resp.Body = &gzipReadCloser{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// do runs the hooks and sends the request. With WithPanicRecovery a panic of
+// the transport or a hook is returned as an error carrying the stack.
+func (c *Client) do(req *http.Request) (resp *http.Response, err error) {
+	if c.panicRecovery {
+		defer func() {
+			if r := recover(); r != nil {
+				if resp != nil {
+					resp.Body.Close()
+				}
+				resp, err = nil, fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+	}
+	for _, hook := range c.requestHooks {
+		hook(req)
+	}
+	start := time.Now()
+	resp, err = c.getDoer().Do(req)
+	for _, hook := range c.responseHooks {
+		hook(resp, time.Since(start))
+	}
+	return resp, err
+}
+
+// gzipReadCloser closes both the gzip reader and the response body it reads.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// limitBody limits the bytes read from a response body to the maximum set with
+// WithMaxResponseSize, if any.
+func (c *Client) limitBody(body io.ReadCloser) io.ReadCloser {
+	if c.maxResponseSize <= 0 {
+		return body
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(body, c.maxResponseSize+1), body: body, limit: c.maxResponseSize}
+}
+
+// limitedReadCloser fails with ErrResponseTooLarge once more than limit bytes
+// are read. It reads one byte past the limit to tell a body of exactly limit
+// bytes from a larger one. Reads after the failure return the same error.
+type limitedReadCloser struct {
+	io.Reader
+	body  io.ReadCloser
+	limit int64
+	read  int64
+	err   error
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	n, err := l.Reader.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		l.err = fmt.Errorf("%w: body exceeds %d bytes", ErrResponseTooLarge, l.limit)
+		// only the bytes up to the limit are returned
+		return n - int(l.read-l.limit), l.err
+	}
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.body.Close()
+}
+
+// responseError builds the *Error of an error response.
+func (c *Client) responseError(req *http.Request, resp *http.Response, respBody []byte) error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  responseRequestID(req, resp),
+	}
+	err := json.Unmarshal(respBody, &apiErr.Response)
+	if err != nil {
+		// e.g. an HTML error page from a proxy, keep the status and the
+		// raw body rather than failing with a JSON error
+		apiErr.Response = ErrorResponse{ErrorMessage: http.StatusText(resp.StatusCode)}
+		apiErr.Body = string(respBody)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), c.clock())
+	}
+	return apiErr
+}
+
+// responseRequestID returns the request ID echoed by the server, or the one
+// sent when the server did not echo it.
+func responseRequestID(req *http.Request, resp *http.Response) string {
+	if id := resp.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+	return req.Header.Get(requestIDHeader)
+}
+
+// newNonce returns a fresh nonce for signing a request, its timestamp is in
+// the configured nonce resolution.
+func (c *Client) newNonce() string {
+	now := c.clock()
+	if c.nonceResolution == time.Millisecond {
+		return c.nonceGenerator.Nonce(now.UnixMilli())
+	}
+	return c.nonceGenerator.Nonce(now.Unix())
+}
+
+// authorize sets the Authorization header of the request, the bearer token
+// when one is configured and the HMAC signature otherwise. It returns the
+// signed nonce, empty in bearer mode.
+func (c *Client) authorize(req *http.Request, body []byte, apiKey, apiSecret, bearerToken string) string {
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+		return ""
+	}
+	return signRequest(req, body, apiKey, apiSecret, c.newNonce(), c.signature)
+}
+
+// signRequest sets the Authorization header of the request signed with the
+// nonce and returns the nonce. The algorithm is only tagged in the header
+// when it is not the default HMAC-SHA512, which servers assume when absent.
+func signRequest(req *http.Request, body []byte, apiKey, apiSecret, nonce string, algorithm SignatureAlgorithm) string {
+	signature := sign(algorithm, apiSecret, req.Method, req.URL.Path, nonce, body)
+	authorization := fmt.Sprintf("APIKey=%s, Signature=%s, Nonce=%s", apiKey, signature, nonce)
+	if algorithm != SignatureHMACSHA512 {
+		authorization += fmt.Sprintf(", Algorithm=%s", algorithm)
+	}
+	req.Header.Set("Authorization", authorization)
+	return nonce
+}
+
+// signedMessage combines the method, endpoint, nonce and body into the
+// message to sign.
+func signedMessage(method, endpoint, nonce string, body []byte) []byte {
+	message := []byte(method + endpoint + nonce)
+	return append(message, body...)
+}
+
+// Resign recomputes the nonce and signature of a previously built request,
+// e.g. one captured for replay after the nonce window has passed. The body
+// is re-read through req.GetBody when available.
+func (c *Client) Resign(req *http.Request) error {
+	body, err := bufferBody(req)
+	if err != nil {
+		return fmt.Errorf("error reading body: %w", err)
+	}
+
+	c.mutex.RLock()
+	apiKey := c.apiKey
+	apiSecret := c.apiSecret
+	bearerToken := c.bearerToken
+	c.mutex.RUnlock()
+
+	c.authorize(req, body, apiKey, apiSecret, bearerToken)
+	return nil
+}
+
+// bufferBody reads the request body and replaces it with a re-readable copy.
+func bufferBody(req *http.Request) ([]byte, error) {
+	var rc io.ReadCloser
+	switch {
+	case req.GetBody != nil:
+		var err error
+		rc, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	case req.Body != nil && req.Body != http.NoBody:
+		rc = req.Body
+	default:
+		return nil, nil
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// endpointTimeout returns the timeout configured for the endpoint. An exact
+// pattern match wins, otherwise the longest matching pattern is used.
+func (c *Client) endpointTimeout(endpoint string) (time.Duration, bool) {
+	var timeout time.Duration
+	var matched string
+	found := false
+	for pattern, d := range c.endpointTimeouts {
+		if pattern == endpoint {
+			return d, true
+		}
+		if ok, _ := path.Match(pattern, endpoint); ok && (!found || len(pattern) > len(matched)) {
+			timeout, matched, found = d, pattern, true
+		}
+	}
+	return timeout, found
+}
+
+// doRequestString performs the request and decodes an acknowledgement body,
+// which may be a bare JSON string or a SuccessResponse wrapping one.
+func (c *Client) doRequestString(ctx context.Context, method, endpoint string, query map[string][]string, body interface{}, headers http.Header, opts ...RequestOption) (string, error) {
+	res, err := c.doRequest(ctx, method, endpoint, query, body, headers, opts...)
+	if err != nil {
+		return "", err
+	}
+	if res.empty() {
+		return "", nil
+	}
+	return decodeString(res.Body)
+}
+
+func decodeString(body []byte) (string, error) {
+	var s string
+	if err := json.Unmarshal(body, &s); err == nil {
+		return s, nil
+	}
+	var r SuccessResponse[string]
+	err := json.Unmarshal(body, &r)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal string JSON data: %w", err)
+	}
+	return r.Data, nil
+}
+
+// Do sends a signed request to an arbitrary endpoint, e.g. one not yet
+// wrapped by a service. The returned APIResponse.Body holds the raw JSON
+// response which the caller must unmarshal.
+func (c *Client) Do(ctx context.Context, method, endpoint string, query map[string][]string, body interface{}, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, method, endpoint, query, body, nil, opts...)
+}
+
+// GetStream sends a signed GET request and returns the live response body
+// without buffering it, e.g. for large downloads. The caller must close the
+// body. Error responses are read in full and returned as *Error. Streamed
+// responses are neither retried, cached nor digest verified, and only the
+// header, query and timeout request options apply, the timeout covering the
+// read of the body.
+func (c *Client) GetStream(ctx context.Context, endpoint string, query map[string][]string, opts ...RequestOption) (io.ReadCloser, http.Header, error) {
+	options := newRequestOptions(opts)
+	ctx, query, headers := options.apply(ctx, query, nil)
+	cancel := context.CancelFunc(func() {})
+	if options.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+	}
+	body, header, err := c.openStream(ctx, endpoint, query, headers)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return &cancelReadCloser{ReadCloser: body, cancel: cancel}, header, nil
+}
+
+// openStream signs and sends a GET request, returning the unread body of a
+// successful response.
+func (c *Client) openStream(ctx context.Context, endpoint string, query map[string][]string, headers http.Header) (io.ReadCloser, http.Header, error) {
+	sr, err := c.newSignedRequest(ctx, http.MethodGet, endpoint, query, nil, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, nonce, debug := sr.req, sr.nonce, sr.debug
+
+	req, endSpan := c.startSpan(req)
+
+	if debug {
+		err = c.dumpRequest(req, false, sr.apiKey, sr.apiSecret, sr.bearerToken)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	start := time.Now()
+	resp, err := c.roundTrip(req)
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		var respBody []byte
+		resp.Body = c.limitBody(resp.Body)
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("response read failed: %w", err)
+		} else {
+			err = c.responseError(req, resp, respBody)
+		}
+	}
+	if err != nil {
+		endSpan(nil, err)
+		c.logRequest(ctx, req, nonce, time.Since(start), nil, err)
+		return nil, nil, err
+	}
+	if debug {
+		// the body is streamed to the caller, so it is left out of the dump
+		dump, err := httputil.DumpResponse(resp, false)
+		if err == nil {
+			c.writeDump(dump)
+		}
+	}
+	res := &APIResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Headers:    resp.Header,
+		RequestID:  responseRequestID(req, resp),
+	}
+	endSpan(res, nil)
+	c.logRequest(ctx, req, nonce, time.Since(start), res, nil)
+	return resp.Body, resp.Header, nil
+}
+
+// cancelReadCloser releases the context of a streamed response on Close.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, query map[string][]string, headers http.Header, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, http.MethodGet, endpoint, query, nil, headers, opts...)
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, query map[string][]string, body interface{}, headers http.Header, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, http.MethodPost, endpoint, query, body, headers, opts...)
 }
 
-func (c *Client) post(ctx context.Context, endpoint string, query map[string][]string, body interface{}, headers http.Header) (*APIResponse, error) {
-	return c.doRequest(ctx, http.MethodPost, endpoint, query, body, headers)
+func (c *Client) put(ctx context.Context, endpoint string, query map[string][]string, body interface{}, headers http.Header, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, http.MethodPut, endpoint, query, body, headers, opts...)
 }
 
-func (c *Client) put(ctx context.Context, endpoint string, query map[string][]string, body interface{}, headers http.Header) (*APIResponse, error) {
-	return c.doRequest(ctx, http.MethodPut, endpoint, query, body, headers)
+func (c *Client) patch(ctx context.Context, endpoint string, query map[string][]string, body interface{}, headers http.Header, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, http.MethodPatch, endpoint, query, body, headers, opts...)
 }
 
-func (c *Client) delete(ctx context.Context, endpoint string, query map[string][]string, headers http.Header) (*APIResponse, error) {
-	return c.doRequest(ctx, http.MethodDelete, endpoint, query, nil, headers)
+func (c *Client) delete(ctx context.Context, endpoint string, query map[string][]string, headers http.Header, opts ...RequestOption) (*APIResponse, error) {
+	return c.doRequest(ctx, http.MethodDelete, endpoint, query, nil, headers, opts...)
 }
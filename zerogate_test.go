@@ -2,15 +2,22 @@ package zerogate
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -86,20 +93,23 @@ func TestClient_Headers(t *testing.T) {
 }
 
 func testSignature(c *gin.Context, t *testing.T) {
+	testSignatureWithSecret(c, t, testApiSecret)
+}
+
+func testSignatureWithSecret(c *gin.Context, t *testing.T, secret string) {
 	// Get the authorization header
 	authHeader := c.Request.Header.Get("Authorization")
 	assert.NotEmpty(t, authHeader, "empty Authorization header")
 
 	// Split the authorization header into its components
 	authParts := strings.Split(authHeader, ", ")
-	if len(authParts) != 3 {
-		assert.NoError(t, nil, "invalid Authorization header")
+	if len(authParts) != 3 && len(authParts) != 4 {
+		assert.Fail(t, "invalid Authorization header", authHeader)
 		return
 	}
 
 	// Parse the authorization header for the API key, signature, and nonce
-	var apiKey, signature string
-	var nonce int64
+	var apiKey, signature, nonce string
 
 	if n, err := fmt.Sscanf(authParts[0], "APIKey=%s", &apiKey); err != nil || n != 1 {
 		assert.NoError(t, err, "invalid Authorization header")
@@ -109,20 +119,46 @@ func testSignature(c *gin.Context, t *testing.T) {
 		assert.NoError(t, err, "invalid Authorization header")
 		return
 	}
-	if n, err := fmt.Sscanf(authParts[2], "Nonce=%d", &nonce); err != nil || n != 1 {
+	if n, err := fmt.Sscanf(authParts[2], "Nonce=%s", &nonce); err != nil || n != 1 {
 		assert.NoError(t, err, "invalid Authorization header")
 		return
 	}
 
+	// The algorithm is only tagged when it is not the default HMAC-SHA512
+	newHash := sha512.New
+	if len(authParts) == 4 {
+		var algorithm string
+		if n, err := fmt.Sscanf(authParts[3], "Algorithm=%s", &algorithm); err != nil || n != 1 {
+			assert.NoError(t, err, "invalid Authorization header")
+			return
+		}
+		switch algorithm {
+		case "HMAC-SHA256":
+			newHash = sha256.New
+		default:
+			assert.Fail(t, "unexpected signature algorithm", algorithm)
+			return
+		}
+	}
+
+	// The nonce timestamp is in seconds or milliseconds depending on the
+	// resolution the client was configured with
+	timestamp, err := nonceTimestamp(nonce)
+	if err != nil {
+		assert.NoError(t, err, "invalid nonce")
+		return
+	}
+	assert.WithinDuration(t, time.Now(), nonceTime(timestamp), time.Minute, "nonce timestamp is not current")
+
 	// Combine the HTTP method, endpoint, nonce, and request body into the message to sign
 	method := c.Request.Method
 	endpoint := c.Request.URL.Path
-	message := method + endpoint + fmt.Sprint(nonce)
+	message := method + endpoint + nonce
 
-	// Create an HMAC-SHA512 hash using the API secret as the key
-	h := hmac.New(sha512.New, []byte(testApiSecret))
+	// Create an HMAC hash using the API secret as the key
+	h := hmac.New(newHash, []byte(secret))
 	h.Write([]byte(message))
-	if method == http.MethodPost || method == http.MethodPut {
+	if c.Request.Body != nil {
 		bodyBytes, err := io.ReadAll(c.Request.Body)
 		if err != nil {
 			assert.NoError(t, err, "error reading body")
@@ -137,6 +173,127 @@ func testSignature(c *gin.Context, t *testing.T) {
 	assert.Equalf(t, signature, reqSignature, "signature mismatch expected %s got %s", signature, reqSignature)
 }
 
+func TestClient_Patch(t *testing.T) {
+	setup()
+	defer teardown()
+	router.PATCH("/patch", func(c *gin.Context) {
+		assert.Equal(t, "application/json", c.Request.Header.Get("Content-Type"))
+		testSignature(c, t)
+		var body map[string]string
+		assert.NoError(t, c.BindJSON(&body))
+		assert.Equal(t, map[string]string{"name": "renamed"}, body, "body should be sent")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	res, err := client.patch(context.Background(), "/patch", nil, map[string]string{"name": "renamed"}, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestClient_DeleteWithBody(t *testing.T) {
+	setup()
+	defer teardown()
+	router.DELETE("/delete", func(c *gin.Context) {
+		testSignature(c, t)
+		var body map[string][]string
+		assert.NoError(t, c.BindJSON(&body))
+		assert.Equal(t, map[string][]string{"ids": {"a", "b"}}, body, "body should be sent")
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.DELETE("/empty", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Zero(t, c.Request.ContentLength, "no body should be sent")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	_, err := client.doRequest(context.Background(), http.MethodDelete, "/delete", nil, map[string][]string{"ids": {"a", "b"}}, nil)
+	assert.NoError(t, err)
+	_, err = client.delete(context.Background(), "/empty", nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestClient_FormBody(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/form", func(c *gin.Context) {
+		assert.Equal(t, "application/x-www-form-urlencoded", c.Request.Header.Get("Content-Type"))
+		testSignature(c, t)
+		assert.Equal(t, "bob", c.PostForm("name"))
+		assert.Equal(t, []string{"admin", "dev"}, c.PostFormArray("role"))
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.POST("/json", func(c *gin.Context) {
+		assert.Equal(t, "application/json", c.Request.Header.Get("Content-Type"))
+		testSignature(c, t)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	form := url.Values{"name": {"bob"}, "role": {"admin", "dev"}}
+	_, err := client.post(context.Background(), "/form", nil, form, nil)
+	assert.NoError(t, err)
+	_, err = client.post(context.Background(), "/json", nil, struct{ Name string }{"bob"}, nil)
+	assert.NoError(t, err)
+}
+
+func TestClient_GetStream(t *testing.T) {
+	setup()
+	defer teardown()
+	const chunk = 1 << 20
+	const chunks = 8
+	firstRead := make(chan struct{})
+	router.GET("/download", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "v1", c.Query("version"))
+		c.Header("Content-Type", "application/octet-stream")
+		c.Status(http.StatusOK)
+		data := bytes.Repeat([]byte("x"), chunk)
+		c.Writer.Write(data)
+		c.Writer.Flush()
+		// the rest is only sent once the client has read the first chunk,
+		// which it cannot do if the response is buffered in full
+		select {
+		case <-firstRead:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "response was not streamed")
+			return
+		}
+		for i := 1; i < chunks; i++ {
+			c.Writer.Write(data)
+		}
+	})
+
+	body, header, err := client.GetStream(context.Background(), "/download", map[string][]string{"version": {"v1"}})
+	if !assert.NoError(t, err) {
+		close(firstRead)
+		return
+	}
+	defer body.Close()
+	assert.Equal(t, "application/octet-stream", header.Get("Content-Type"))
+	_, err = io.ReadFull(body, make([]byte, chunk))
+	assert.NoError(t, err)
+	close(firstRead)
+	n, err := io.Copy(io.Discard, body)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(chunk*(chunks-1)), n, "the whole payload should be received")
+}
+
+func TestClient_GetStreamError(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/download", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, newErrorResponse(http.StatusNotFound, errors.New("file not found")))
+	})
+
+	body, _, err := client.GetStream(context.Background(), "/download", nil)
+	assert.Nil(t, body)
+	assert.True(t, IsNotFound(err), "error response should be returned as *Error")
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, "file not found", apiErr.Response.ErrorMessage)
+	}
+}
+
 func TestContextTimeout(t *testing.T) {
 	setup()
 	defer teardown()
@@ -156,3 +313,351 @@ func TestContextTimeout(t *testing.T) {
 	assert.WithinDuration(t, start, time.Now(), 2*time.Second,
 		"doRequest took too much time with an expiring context")
 }
+
+func TestClient_DoRequestString(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/bare", func(c *gin.Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	router.GET("/envelope", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newSuccessResponse("accepted"))
+	})
+	router.GET("/object", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": 1})
+	})
+
+	res, err := client.doRequestString(context.Background(), http.MethodGet, "/bare", nil, nil, nil)
+	assert.NoError(t, err, "bare string decode error")
+	assert.Equal(t, "ok", res)
+
+	res, err = client.doRequestString(context.Background(), http.MethodGet, "/envelope", nil, nil, nil)
+	assert.NoError(t, err, "envelope string decode error")
+	assert.Equal(t, "accepted", res)
+
+	_, err = client.doRequestString(context.Background(), http.MethodGet, "/object", nil, nil, nil)
+	assert.Error(t, err, "non string data should not decode")
+}
+
+func TestClient_EmptyQuery(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/query", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, c.Request.RequestURI)
+	})
+
+	for _, query := range []map[string][]string{nil, {}, {"empty": {}}} {
+		res, err := client.doRequestString(context.Background(), http.MethodGet, "/query", query, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "/query", res, "parameterless request should have no query string")
+	}
+
+	res, err := client.doRequestString(context.Background(), http.MethodGet, "/query", map[string][]string{"page": {"1"}}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "/query?page=1", res)
+}
+
+func TestClient_BuildURL(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/query", func(c *gin.Context) {
+		testSignature(c, t)
+		c.JSON(http.StatusOK, c.Request.RequestURI)
+	})
+	assert.Equal(t, server.URL, client.BaseURL())
+
+	for _, query := range []map[string][]string{nil, {"empty": {}}, {"q": {"a b&c"}, "ids": {"2", "1"}, "page": {"1"}}} {
+		res, err := client.doRequestString(context.Background(), http.MethodGet, "/query", query, nil, nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, client.buildURL("/query", query), server.URL+res, "preview should match the sent URL")
+		}
+	}
+}
+
+func TestClient_Resign(t *testing.T) {
+	setup()
+	defer teardown()
+	var nonces []string
+	router.POST("/replay", func(c *gin.Context) {
+		testSignature(c, t)
+		body, _ := io.ReadAll(c.Request.Body)
+		assert.JSONEq(t, `{"name":"Test"}`, string(body), "body should be replayed intact")
+		nonces = append(nonces, strings.Split(c.Request.Header.Get("Authorization"), "Nonce=")[1])
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/replay", strings.NewReader(`{"name":"Test"}`))
+	if err != nil {
+		assert.NoError(t, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	assert.NoError(t, client.Resign(req))
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	// simulate a replay after the nonce has gone stale
+	assert.NoError(t, client.Resign(req))
+	resp, err = http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	if assert.Len(t, nonces, 2) {
+		assert.NotEqual(t, nonces[0], nonces[1], "resigned request should carry a fresh nonce")
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/beta/widgets", func(c *gin.Context) {
+		testSignature(c, t)
+		assert.Equal(t, "1", c.Query("dry_run"))
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil {
+			assert.NoError(t, err)
+			return
+		}
+		c.JSON(http.StatusCreated, newSuccessResponse(body))
+	})
+
+	res, err := client.Do(context.Background(), http.MethodPost, "/beta/widgets",
+		map[string][]string{"dry_run": {"1"}}, map[string]string{"name": "widget"})
+	if err != nil {
+		assert.NoError(t, err)
+		return
+	}
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.JSONEq(t, `{"success":true,"data":{"name":"widget"}}`, string(res.Body))
+}
+
+func TestClient_AcceptFallback(t *testing.T) {
+	setup(WithAcceptFallback())
+	defer teardown()
+	var accepts []string
+	handler := func(c *gin.Context) {
+		testSignature(c, t)
+		accepts = append(accepts, c.Request.Header.Get("Accept"))
+		if c.Request.Header.Get("Accept") == "application/json" {
+			c.JSON(http.StatusNotAcceptable, newErrorsResponse(http.StatusNotAcceptable, "not acceptable"))
+			return
+		}
+		c.JSON(http.StatusOK, "ok")
+	}
+	router.GET("/negotiate", handler)
+
+	res, err := client.doRequestString(context.Background(), http.MethodGet, "/negotiate", nil, nil, nil)
+	assert.NoError(t, err, "request should succeed with the broader Accept")
+	assert.Equal(t, "ok", res)
+	assert.Equal(t, []string{"application/json", "*/*"}, accepts)
+
+	// without the option the 406 is returned
+	teardown()
+	setup()
+	router.GET("/negotiate", handler)
+	_, err = client.get(context.Background(), "/negotiate", nil, nil)
+	var apiErr *Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusNotAcceptable, apiErr.StatusCode)
+	}
+}
+
+type countingNonceGenerator struct {
+	calls int
+}
+
+func (g *countingNonceGenerator) Nonce(timestamp int64) string {
+	g.calls++
+	return randomNonceGenerator{}.Nonce(timestamp)
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	setup()
+	defer teardown()
+	router.POST("/tenants", func(c *gin.Context) {
+		assert.Equal(t, "Bearer tok_3f9a1c7e5b2d", c.Request.Header.Get("Authorization"))
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+	router.POST("/tenants/import", func(c *gin.Context) {
+		assert.Equal(t, "Bearer tok_3f9a1c7e5b2d", c.Request.Header.Get("Authorization"))
+		io.ReadAll(c.Request.Body)
+		assert.Empty(t, c.Request.Trailer.Get("X-Body-Signature"), "no body signature should be sent")
+		c.JSON(http.StatusOK, newSuccessResponse(ImportResult{Imported: 1}))
+	})
+	nonces := &countingNonceGenerator{}
+	bearerClient, err := New("", "", WithBearerToken("tok_3f9a1c7e5b2d"), WithNonceGenerator(nonces), BaseURL(server.URL))
+	if !assert.NoError(t, err, "client creation failed") {
+		return
+	}
+
+	tenant, err := bearerClient.Tenant.Create(context.TODO(), &TenantCreateRequest{Name: "Test"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test", tenant.Name)
+	}
+	_, err = bearerClient.Tenant.ImportStream(context.TODO(), strings.NewReader(`{"name":"Test"}`+"\n"))
+	assert.NoError(t, err)
+	assert.Zero(t, nonces.calls, "no nonce should be computed in bearer mode")
+}
+
+func TestClient_BearerTokenExclusive(t *testing.T) {
+	_, err := New(testApiKey, testApiSecret, WithBearerToken("tok_3f9a1c7e5b2d"))
+	assert.EqualError(t, err, errConflictingCredentials)
+	_, err = New(testApiKey, "", WithBearerToken("tok_3f9a1c7e5b2d"))
+	assert.EqualError(t, err, errConflictingCredentials)
+	_, err = New("", "")
+	assert.EqualError(t, err, errEmptyCredentials)
+	_, err = New("", "", WithBearerToken(""))
+	assert.Error(t, err, "empty bearer token should be rejected")
+
+	bearerClient, err := New("", "", WithBearerToken("tok_3f9a1c7e5b2d"))
+	if assert.NoError(t, err) {
+		assert.EqualError(t, bearerClient.SetCredentials(testApiKey, testApiSecret), errConflictingCredentials)
+	}
+	hmacClient, err := New(testApiKey, testApiSecret)
+	if assert.NoError(t, err) {
+		_, err = hmacClient.Clone(WithBearerToken("tok_3f9a1c7e5b2d"))
+		assert.EqualError(t, err, errConflictingCredentials)
+	}
+}
+
+func TestClient_BearerTokenRedacted(t *testing.T) {
+	setup()
+	defer teardown()
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		c.JSON(http.StatusOK, newSuccessResponse(&Tenant{Name: "Test"}))
+	})
+	for _, token := range []string{"ab+c/d=", "tok_(x[y"} {
+		var buf bytes.Buffer
+		bearerClient, err := New("", "", WithBearerToken(token), Debug(true), WithDebugWriter(&buf), BaseURL(server.URL))
+		if !assert.NoError(t, err, "client creation failed") {
+			return
+		}
+		_, err = bearerClient.Tenant.Get(context.TODO(), "ten_1")
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "Bearer [**************]", "token %q should be redacted", token)
+		assert.NotContains(t, buf.String(), token)
+	}
+}
+
+func TestClient_SetCredentials(t *testing.T) {
+	setup()
+	defer teardown()
+	const rotatedKey = "key_0b8c3f0e6d2a4f7c9e1b5a3d7f9c2e4a"
+	const rotatedSecret = "8a2f4c6e1b3d5f7a9c0e2b4d6f8a1c3e5b7d9f0a2c4e6b8d1f3a5c7e9b0d2f4a"
+	secrets := map[string]string{testApiKey: testApiSecret, rotatedKey: rotatedSecret}
+	router.GET("/rotate", func(c *gin.Context) {
+		params, err := parseAuthorization(c.Request.Header.Get("Authorization"))
+		if err != nil {
+			assert.NoError(t, err, "invalid Authorization header")
+			return
+		}
+		secret, ok := secrets[params["APIKey"]]
+		if !assert.True(t, ok, "unknown API key %q", params["APIKey"]) {
+			return
+		}
+		// every request must be signed with the secret matching its key
+		testSignatureWithSecret(c, t, secret)
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := client.get(context.Background(), "/rotate", nil, nil)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			assert.NoError(t, client.SetCredentials(rotatedKey, rotatedSecret))
+		} else {
+			assert.NoError(t, client.SetCredentials(testApiKey, testApiSecret))
+		}
+	}
+	wg.Wait()
+
+	assert.Error(t, client.SetCredentials("", rotatedSecret), "empty key should be rejected")
+	assert.Error(t, client.SetCredentials(rotatedKey, ""), "empty secret should be rejected")
+	assert.Equal(t, testApiKey, client.apiKey, "rejected credentials should not be applied")
+}
+
+func TestClient_GzipResponse(t *testing.T) {
+	setup(WithHeader("Accept-Encoding", "gzip"), Debug(true), WithLogger(log.New(io.Discard, "", 0)))
+	defer teardown()
+	gzipJSON := func(v interface{}) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		json.NewEncoder(zw).Encode(v)
+		zw.Close()
+		return buf.Bytes()
+	}
+	router.GET("/tenants/:tenantId", func(c *gin.Context) {
+		tenant := &Tenant{Base: Base{Id: c.Param("tenantId")}, Name: "Test"}
+		switch c.Param("tenantId") {
+		case "plain":
+			// gzip was accepted but not used, the body must be read as is
+			c.JSON(http.StatusOK, newSuccessResponse(tenant))
+		case "corrupt":
+			c.Header("Content-Encoding", "gzip")
+			c.Data(http.StatusOK, "application/json", []byte("not gzip"))
+		default:
+			c.Header("Content-Encoding", "gzip")
+			c.Data(http.StatusOK, "application/json", gzipJSON(newSuccessResponse(tenant)))
+		}
+	})
+
+	for _, id := range []string{"ten_gzip", "plain"} {
+		res, err := client.get(context.Background(), "/tenants/"+id, nil, nil)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var r SuccessResponse[*Tenant]
+		if assert.NoError(t, json.Unmarshal(res.Body, &r), "body should be decompressed JSON") {
+			assert.Equal(t, id, r.Data.Id)
+			assert.Equal(t, "Test", r.Data.Name)
+		}
+		assert.Empty(t, res.Headers.Get("Content-Encoding"), "decoded response should not advertise an encoding")
+	}
+
+	_, err := client.get(context.Background(), "/tenants/corrupt", nil, nil)
+	assert.ErrorContains(t, err, "decompress", "corrupt gzip body should fail")
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("ZEROGATE_API_KEY", "")
+	t.Setenv("ZEROGATE_API_SECRET", "")
+	t.Setenv("ZEROGATE_BASE_URL", "")
+
+	_, err := NewFromEnv()
+	assert.ErrorContains(t, err, "ZEROGATE_API_KEY", "missing key should be named")
+	t.Setenv("ZEROGATE_API_KEY", testApiKey)
+	_, err = NewFromEnv()
+	assert.ErrorContains(t, err, "ZEROGATE_API_SECRET", "missing secret should be named")
+	t.Setenv("ZEROGATE_API_SECRET", testApiSecret)
+
+	client, err := NewFromEnv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, testApiKey, client.apiKey)
+		assert.Equal(t, testApiSecret, client.apiSecret)
+		assert.Equal(t, baseUrl, client.baseUrl, "default base url should be used")
+	}
+
+	t.Setenv("ZEROGATE_BASE_URL", "http://localhost:8080/public/v1")
+	client, err = NewFromEnv(WithUserAgent("app/1.0"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://localhost:8080/public/v1", client.baseUrl)
+		assert.Equal(t, "app/1.0 zerogate-go", client.userAgent, "options should still apply")
+	}
+	client, err = NewFromEnv(BaseURL("http://override/public/v1"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://override/public/v1", client.baseUrl, "BaseURL option should override the environment")
+	}
+}